@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+type AscentKind int
+
+const (
+	AscentNone AscentKind = iota
+	AscentAltitude
+	AscentDock
+)
+
+type Phase int
+
+const (
+	PhaseDescent Phase = iota
+	PhaseAscentReady
+	PhaseAscent
+)
+
+// the descent stage is left on the pad so the ascent stage is lighter,
+// as a fraction of the ship mass
+const descentStageMass = 0.4
+const ascentFuel = 60.0
+
+// target altitude as a fraction of the screen height in sub-pixels
+const ascentTargetAltitude = 0.15
+
+// commandModulePass is the frames the command module takes to cross
+// the screen, whatever its width
+const commandModulePass = 20 * 60
+
+// dockDrift is how far the ship's speed across can be off the command
+// module's and still dock, as a fraction of the module's speed
+const dockDrift = 0.75
+
+type CommandModule struct {
+	X     float64
+	Y     float64
+	OldX  float64
+	Speed float64
+}
+
+func newCommandModule(c *Canvas) CommandModule {
+	y := math.Max(float64(c.Height)*ascentTargetAltitude, float64(3*c.CellH))
+	return CommandModule{X: 0, Y: y, OldX: 0, Speed: float64(c.Width) / commandModulePass}
+}
+
+// update moves the command module across the top of the screen, it
+// wraps around so the pilot can wait for another pass.
+func (cm *CommandModule) update(c *Canvas) {
+	cm.OldX = cm.X
+	cm.X += cm.Speed
+	if int(cm.X) >= c.Width {
+		cm.X = 0
+	}
+}
+
+func (cm *CommandModule) draw(s tcell.Screen, c *Canvas) {
+	style := tcell.StyleDefault.Foreground(color.White).Background(color.Black)
+	label := []rune("<=#=>")
+	y := int(cm.Y) / c.CellH
+	for i, r := range label {
+		s.SetContent(int(cm.X)/c.CellW-2+i, y, r, nil, style)
+	}
+}
+
+// docking reports whether the ship is close enough to the command module
+// to dock.
+func (cm *CommandModule) docking(playerX, playerY float64) bool {
+	return math.Abs(playerX-cm.X) <= 2 && math.Abs(playerY-cm.Y) <= 1.5
+}
+
+// drifting reports whether the ship is moving across too differently to
+// the command module to dock, speedX in sub-pixels a frame.
+func (cm *CommandModule) drifting(speedX float64) bool {
+	return math.Abs(speedX-cm.Speed) > cm.Speed*dockDrift
+}
+
+func drawTargetAltitude(s tcell.Screen, c *Canvas, y int) {
+	style := tcell.StyleDefault.Foreground(color.DarkCyan).Background(color.Black)
+	width, _ := s.Size()
+	for x := 0; x < width; x += 2 {
+		s.SetContent(x, y/c.CellH, '-', nil, style)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+// Scene is one part of the attract sequence. Start is called each time
+// the scene comes round and after a resize, Draw every frame with the
+// time since it started.
+type Scene struct {
+	Name     string
+	Duration time.Duration
+	Start    func(s tcell.Screen, r *Renderer)
+	Draw     func(s tcell.Screen, r *Renderer, elapsed time.Duration)
+}
+
+// how long the menu waits for a key before the attract sequence starts
+const menuIdleTime = 30 * time.Second
+
+const attractFps = 30
+
+// attractSceneMakers are the scenes -attract can pick from by name
+var attractSceneMakers = map[string]func() Scene{
+	"logo":    logoScene,
+	"demo":    demoScene,
+	"scores":  highScoreScene,
+	"credits": creditsScene,
+}
+
+// attractSequence builds the scenes from a list like "logo,demo:40s"
+// giving their order and, after a colon, how long each one shows for.
+// Anything it doesn't understand is logged and left out, and if that
+// leaves nothing the usual sequence is used.
+func attractSequence(spec string) []Scene {
+	scenes := []Scene{}
+	for _, item := range strings.Split(spec, ",") {
+		name, timing, timed := strings.Cut(strings.TrimSpace(item), ":")
+		maker, ok := attractSceneMakers[strings.ToLower(name)]
+		if !ok {
+			log.Println("No attract scene called", name)
+			continue
+		}
+		scene := maker()
+		if timed {
+			duration, err := time.ParseDuration(timing)
+			if err != nil || duration <= 0 {
+				log.Println("Attract scene", name, "has a bad time", timing)
+				continue
+			}
+			scene.Duration = duration
+		}
+		scenes = append(scenes, scene)
+	}
+	if len(scenes) == 0 {
+		return attractSequence(defaultSettings.Attract)
+	}
+	return scenes
+}
+
+// runAttract cycles the scenes until a key is pressed.
+func runAttract(s tcell.Screen, scenes []Scene) {
+	renderer := chooseRenderer(s, settings.Renderer)
+	for index := 0; ; index = (index + 1) % len(scenes) {
+		scene := scenes[index]
+		log.Println("Attract scene", scene.Name)
+		if scene.Start != nil {
+			scene.Start(s, renderer)
+		}
+		started := time.Now()
+		for time.Since(started) < scene.Duration {
+			s.Clear()
+			scene.Draw(s, renderer, time.Since(started))
+			s.Show()
+
+			select {
+			case ev := <-s.EventQ():
+				switch ev.(type) {
+				case *tcell.EventKey:
+					s.Clear()
+					return
+				case *tcell.EventResize:
+					s.Sync()
+					if scene.Start != nil {
+						scene.Start(s, renderer)
+					}
+				}
+			case <-time.After(time.Second / attractFps):
+			}
+		}
+	}
+}
+
+const logoScrollTime = 12 * time.Second
+
+func logoScene() Scene {
+	var logo *Sprite
+	return Scene{
+		Name:     "Logo",
+		Duration: logoScrollTime,
+		Start: func(s tcell.Screen, r *Renderer) {
+			logo = newLogo(s, r, settings.LogoText)
+		},
+		Draw: func(s tcell.Screen, r *Renderer, elapsed time.Duration) {
+			// scroll in from the right and off the left, coming round
+			// again if the scene is shown for longer
+			width, _ := s.Size()
+			travel := width + (logo.W+r.CellW-1)/r.CellW
+			scrolled := math.Mod(elapsed.Seconds(), logoScrollTime.Seconds()) / logoScrollTime.Seconds()
+			drawLogo(s, r, width-int(float64(travel)*scrolled), logo)
+		},
+	}
+}
+
+// DemoFlight is the lander flying itself down to a pad for the attract
+// sequence. It has simpler physics than the game, it only has to look
+// right.
+type DemoFlight struct {
+	Terrain    *Canvas
+	Frame      *Canvas
+	Background *Background
+	Pads       []LandingCoOrds
+	Pad        LandingCoOrds
+	Ship       *ShipDesign
+	X, Y       float64
+	SpeedX     float64
+	SpeedY     float64
+	Throttle   float64
+	Landed     int
+	Scale      float64
+}
+
+func newDemoFlight(s tcell.Screen, r *Renderer) *DemoFlight {
+	width, height := s.Size()
+	d := &DemoFlight{
+		Terrain: newCanvas(width, height, r.CellW, r.CellH),
+		Frame:   newCanvas(width, height, r.CellW, r.CellH),
+		Ship:    ships[0],
+		Scale:   float64(r.CellH) / 2,
+	}
+	d.Pads = landscapeSin(d.Terrain, make([]LandingCoOrds, 0))
+	space := fillTerrain(d.Terrain, 5*r.CellW, 5*r.CellH)
+	d.Background = newBackground(d.Terrain, "Demo", space)
+	d.reset()
+	return d
+}
+
+// reset puts the ship back at the top heading for the next landing pad.
+func (d *DemoFlight) reset() {
+	d.X = float64(5 * d.Terrain.CellW)
+	d.Y = float64(5 * d.Terrain.CellH)
+	d.SpeedX, d.SpeedY, d.Landed = 0, 0, 0
+	d.Pad = LandingCoOrds{Start: d.Terrain.Width / 2, End: d.Terrain.Width / 2, Y: d.Terrain.Height}
+	for _, pad := range d.Pads {
+		if pad.Kind == PadLanding && pad.End-pad.Start > 4 {
+			d.Pad = pad
+			break
+		}
+	}
+}
+
+// step flies one frame, the autopilot slides over the pad and then
+// holds the descent to a safe rate that slows as the pad gets closer.
+func (d *DemoFlight) step() {
+	if d.Landed > 0 {
+		d.Landed--
+		if d.Landed == 0 {
+			d.reset()
+		}
+		d.Throttle = 0
+		return
+	}
+	gravity := 0.002 * d.Scale
+	centre := float64(d.Pad.Start+d.Pad.End) / 2
+	d.SpeedX = math.Max(-0.3, math.Min(0.3, (centre-d.X)*0.02)) * d.Scale
+	altitude := float64(d.Pad.Y) - d.Y
+	target := math.Max(0.04, altitude*0.01) * d.Scale
+	if math.Abs(centre-d.X) > 4 {
+		// hover until over the pad
+		target = 0
+	}
+	d.Throttle = 0
+	if d.SpeedY > target {
+		d.Throttle = 1
+	}
+	d.SpeedY += gravity - d.Throttle*gravity*2.5
+	d.X += d.SpeedX
+	d.Y += d.SpeedY
+	for _, foot := range d.Ship.feet() {
+		if d.Terrain.get(int(d.X+foot.X), int(d.Y+foot.Y)+1) != 0 {
+			d.Landed = 3 * attractFps
+		}
+	}
+	if d.Y >= float64(d.Terrain.Height) {
+		d.reset()
+	}
+}
+
+func (d *DemoFlight) draw(s tcell.Screen, r *Renderer) {
+	updateParticles(d.Terrain)
+	emitExhaust(d.Ship, d.X, d.Y, d.Throttle, d.Scale)
+	if settings.Background {
+		d.Background.follow(d.X)
+		d.Background.draw(d.Frame)
+		d.Frame.overlay(d.Terrain)
+	} else {
+		d.Frame.copyFrom(d.Terrain)
+	}
+	drawShip(d.Frame, d.Ship, d.X, d.Y)
+	drawParticles(d.Frame)
+	drawCanvasToScreen(d.Frame, s, r)
+	if asciiMode() {
+		drawASCIIShip(s, d.Frame, d.Ship, d.X, d.Y, d.Throttle)
+	}
+	width, _ := s.Size()
+	style := tcell.StyleDefault.Foreground(color.Yellow).Background(color.Black)
+	drawTextCentre(s, width, 1, style, "DEMO - press any key")
+}
+
+func demoScene() Scene {
+	var demo *DemoFlight
+	return Scene{
+		Name:     "Demo",
+		Duration: 25 * time.Second,
+		Start: func(s tcell.Screen, r *Renderer) {
+			clearParticles()
+			demo = newDemoFlight(s, r)
+		},
+		Draw: func(s tcell.Screen, r *Renderer, elapsed time.Duration) {
+			demo.step()
+			demo.draw(s, r)
+		},
+	}
+}
+
+// drawTitledScene draws a banner title with lines of text under it.
+func drawTitledScene(s tcell.Screen, r *Renderer, title string, lines []string) {
+	width, height := s.Size()
+	c := newCanvas(width, height, r.CellW, r.CellH)
+	drawBannerCentre(c, fitBanner(c, title, 6, logoColours), r.CellH)
+	drawCanvasToScreen(c, s, r)
+	style := tcell.StyleDefault.Foreground(color.White).Background(color.Black)
+	for i, line := range lines {
+		drawTextCentre(s, width, height/2+i, style, line)
+	}
+}
+
+// attractScoreLines are the best few of each difficulty.
+func attractScoreLines() []string {
+	const shown = 3
+	lines := []string{}
+	for _, name := range difficultyNames {
+		scores := highScores.Difficulties[name]
+		if len(scores) == 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, name)
+		for i, entry := range scores[:min(shown, len(scores))] {
+			lines = append(lines, scoreLine(i, entry))
+		}
+	}
+	if len(lines) == 0 {
+		return []string{"No scores yet"}
+	}
+	return lines
+}
+
+func highScoreScene() Scene {
+	return Scene{
+		Name:     "High Scores",
+		Duration: 8 * time.Second,
+		Draw: func(s tcell.Screen, r *Renderer, elapsed time.Duration) {
+			drawTitledScene(s, r, "HIGH SCORES", attractScoreLines())
+		},
+	}
+}
+
+func creditsScene() Scene {
+	return Scene{
+		Name:     "Credits",
+		Duration: 8 * time.Second,
+		Draw: func(s tcell.Screen, r *Renderer, elapsed time.Duration) {
+			drawTitledScene(s, r, "CREDITS", []string{
+				"Golunar by BernieSoft",
+				"Terminal graphics with tcell",
+				"Roboto font by Google",
+				"",
+				"Press any key",
+			})
+		},
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+)
+
+// Background is the sky behind the terrain. Each layer scrolls at its
+// own rate as the camera follows the ship across the screen, the further
+// away the slower. It is only ever drawn into the frame, collisions look
+// at the terrain canvas so the background can't be hit. Space is the sky
+// the ship can reach, caverns under overhangs included, and nothing is
+// drawn outside it.
+type Background struct {
+	Width     int
+	Screen    int
+	Stars     []Star
+	Mountains []Ridge
+	EarthX    float64
+	EarthY    float64
+	EarthR    float64
+	Space     [][]bool
+	Camera    float64
+	following bool
+}
+
+type Star struct {
+	X, Y   float64
+	Colour Colour
+}
+
+// Ridge is a mountain silhouette, Heights has one entry per sub-pixel
+// column and is wider than the screen so it can scroll.
+type Ridge struct {
+	Heights []int
+	Rate    float64
+	Colour  Colour
+}
+
+const starRate = 0.05
+const earthRate = 0.02
+
+// cameraEase is how much of the way to the ship the camera moves each
+// frame
+const cameraEase = 0.05
+
+// newBackground builds the sky for a level, seeded from the level name
+// so each level always has the same sky, over the space fillTerrain left.
+func newBackground(c *Canvas, name string, space [][]bool) *Background {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	random := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	// layers cover a little more than the screen to allow for scrolling
+	wide := c.Width + c.Width/2
+	b := &Background{Width: wide, Screen: c.Width, Space: space}
+	for i := 0; i < wide*c.Height/150; i++ {
+		b.Stars = append(b.Stars, Star{
+			X:      random.Float64() * float64(wide),
+			Y:      random.Float64() * float64(c.Height),
+			Colour: WHITE.scale(0.3 + random.Float64()*0.7),
+		})
+	}
+
+	ridges := []struct {
+		top, depth, rate float64
+		colour           Colour
+	}{
+		{0.45, 0.15, 0.1, rgb(0x1c, 0x1c, 0x2c)},
+		{0.55, 0.12, 0.2, rgb(0x2c, 0x2c, 0x3c)},
+	}
+	for _, r := range ridges {
+		ridge := Ridge{Rate: r.rate, Colour: r.colour, Heights: make([]int, wide)}
+		phase1 := random.Float64() * math.Pi * 2
+		phase2 := random.Float64() * math.Pi * 2
+		for x := range ridge.Heights {
+			angle := float64(x) / float64(c.Width) * math.Pi * 2
+			wave := math.Sin(angle*2+phase1)*0.6 + math.Sin(angle*5+phase2)*0.3 + math.Sin(angle*13)*0.1
+			ridge.Heights[x] = int(float64(c.Height) * (r.top + r.depth*wave))
+		}
+		b.Mountains = append(b.Mountains, ridge)
+	}
+
+	b.EarthR = float64(3 * c.CellH)
+	b.EarthX = float64(c.Width) * (0.6 + random.Float64()*0.3)
+	b.EarthY = float64(c.Height) * 0.2
+	return b
+}
+
+// wrap keeps a scrolled position inside a layer of the given width.
+func wrap(x float64, width int) int {
+	w := float64(width)
+	return int(math.Mod(math.Mod(x, w)+w, w))
+}
+
+// follow eases the camera towards the ship at x so the sky glides along
+// rather than stepping with every nudge of the side thrusters. The
+// camera is how far the ship is from the middle of the screen in
+// sub-pixels.
+func (b *Background) follow(x float64) {
+	target := x - float64(b.Screen)/2
+	if !b.following {
+		b.Camera, b.following = target, true
+	}
+	b.Camera += (target - b.Camera) * cameraEase
+}
+
+// draw clears the canvas and paints the sky into the space around the
+// terrain.
+func (b *Background) draw(c *Canvas) {
+	c.clear()
+	cameraX := b.Camera
+	for _, star := range b.Stars {
+		c.set(wrap(star.X-cameraX*starRate, b.Width), int(star.Y), star.Colour)
+	}
+	b.drawEarth(c, b.EarthX-cameraX*earthRate, b.EarthY)
+	// the ridges are solid shapes that the glyph only renderers can't
+	// tell apart from the ground
+	if !asciiMode() {
+		for _, ridge := range b.Mountains {
+			for x := 0; x < c.Width; x++ {
+				top := ridge.Heights[wrap(float64(x)+cameraX*ridge.Rate, b.Width)]
+				for y := top; y < c.Height; y++ {
+					c.set(x, y, ridge.Colour)
+				}
+			}
+		}
+	}
+	for y := 0; y < c.Height && y < len(b.Space); y++ {
+		for x := 0; x < c.Width && x < len(b.Space[y]); x++ {
+			if !b.Space[y][x] {
+				c.set(x, y, 0)
+			}
+		}
+	}
+}
+
+// drawEarth draws the Earth half lit by the sun, the dark side faintly
+// showing, with a little green for land.
+func (b *Background) drawEarth(c *Canvas, cx, cy float64) {
+	r := b.EarthR
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			dx := float64(x) - cx + 0.5
+			dy := float64(y) - cy + 0.5
+			if dx*dx+dy*dy > r*r {
+				continue
+			}
+			colour := BLUE
+			if math.Sin(dx*1.3/r*math.Pi)+math.Cos(dy*2.1/r*math.Pi) > 1.1 {
+				colour = GREEN
+			}
+			// the sun is off to the left so the shadow is a circle
+			// shifted right
+			sx := dx - r*0.6
+			if sx*sx+dy*dy < r*r {
+				colour = colour.scale(0.2)
+			}
+			c.set(x, y, colour)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"embed"
+	"image"
+	"log"
+	"os"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+//go:embed Roboto-Regular.ttf
+var fontFile embed.FS
+
+// BannerMask is a string rasterised at one size, Letter gives the
+// index of the letter each column belongs to so banners can be coloured
+// letter by letter.
+type BannerMask struct {
+	W, H   int
+	Pix    []bool
+	Letter []int
+}
+
+type bannerKey struct {
+	text string
+	size int
+}
+
+// the font is parsed once and faces and masks kept for each size as
+// titles are drawn every frame
+var bannerFont *opentype.Font
+var bannerFontFailed bool
+var bannerFaces = map[int]font.Face{}
+var bannerMasks = map[bannerKey]*BannerMask{}
+
+func loadBannerFont() *opentype.Font {
+	if bannerFont != nil || bannerFontFailed {
+		return bannerFont
+	}
+	if settings.FontFile != "" {
+		// a font of the player's choosing, the built in one if it won't load
+		custom, customErr := os.ReadFile(settings.FontFile)
+		if customErr == nil {
+			var fnt *opentype.Font
+			if fnt, customErr = opentype.Parse(custom); customErr == nil {
+				bannerFont = fnt
+				return bannerFont
+			}
+		}
+		log.Println("Font file not usable", settings.FontFile, customErr)
+	}
+	fontBytes, err := fontFile.ReadFile("Roboto-Regular.ttf")
+	if err == nil {
+		bannerFont, err = opentype.Parse(fontBytes)
+	}
+	if err != nil {
+		log.Println("Banner font not loaded", err)
+		bannerFontFailed = true
+	}
+	return bannerFont
+}
+
+func bannerFace(size int) font.Face {
+	if face, ok := bannerFaces[size]; ok {
+		return face
+	}
+	fnt := loadBannerFont()
+	if fnt == nil {
+		return nil
+	}
+	face, err := opentype.NewFace(fnt, &opentype.FaceOptions{
+		Size:    float64(size),
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		log.Println("Banner face not made", err)
+		face = nil
+	}
+	bannerFaces[size] = face
+	return face
+}
+
+// bannerRune swaps letters the font doesn't have for a question mark,
+// or a space if even that is missing.
+func bannerRune(face font.Face, r rune) rune {
+	if _, ok := face.GlyphAdvance(r); ok {
+		return r
+	}
+	Debug("Banner font has no glyph for %q\n", r)
+	if _, ok := face.GlyphAdvance('?'); ok {
+		return '?'
+	}
+	return ' '
+}
+
+// rasteriseBanner draws text with a font size of size sub-pixels,
+// trimmed to the ink.
+// An empty mask comes back if the font can't be used.
+func rasteriseBanner(text string, size int) *BannerMask {
+	key := bannerKey{text, size}
+	if mask, ok := bannerMasks[key]; ok {
+		return mask
+	}
+	mask := &BannerMask{}
+	bannerMasks[key] = mask
+	face := bannerFace(size)
+	if face == nil {
+		return mask
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = bannerRune(face, r)
+	}
+	width := font.MeasureString(face, string(runes)).Ceil()
+	metrics := face.Metrics()
+	height := (metrics.Ascent + metrics.Descent).Ceil()
+	if width <= 0 || height <= 0 {
+		return mask
+	}
+
+	img := image.NewAlpha(image.Rect(0, 0, width, height))
+	letters := make([]int, width)
+	drawer := font.Drawer{Dst: img, Src: image.Opaque, Face: face, Dot: fixed.P(0, metrics.Ascent.Ceil())}
+	prev := rune(-1)
+	for i, r := range runes {
+		if prev >= 0 {
+			drawer.Dot.X += face.Kern(prev, r)
+		}
+		start := drawer.Dot.X.Floor()
+		drawer.DrawString(string(r))
+		for x := max(start, 0); x < min(drawer.Dot.X.Ceil(), width); x++ {
+			letters[x] = i
+		}
+		prev = r
+	}
+
+	// trim the blank rows above the capitals and below the baseline
+	top, bottom := height, 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if img.AlphaAt(x, y).A >= 0x80 {
+				top = min(top, y)
+				bottom = max(bottom, y+1)
+			}
+		}
+	}
+	if top >= bottom {
+		return mask
+	}
+	mask.W = width
+	mask.H = bottom - top
+	mask.Pix = make([]bool, mask.W*mask.H)
+	mask.Letter = letters
+	for y := 0; y < mask.H; y++ {
+		for x := 0; x < mask.W; x++ {
+			mask.Pix[y*mask.W+x] = img.AlphaAt(x, y+top).A >= 0x80
+		}
+	}
+	return mask
+}
+
+// newBanner makes a sprite of text, letters taking the colours in turn.
+func newBanner(text string, size int, colours []Colour) *Sprite {
+	mask := rasteriseBanner(text, size)
+	sprite := &Sprite{W: mask.W, H: mask.H, Pix: make([]Colour, len(mask.Pix))}
+	for i, set := range mask.Pix {
+		if set {
+			sprite.Pix[i] = colours[mask.Letter[i%mask.W]%len(colours)]
+		}
+	}
+	return sprite
+}
+
+// fitBanner picks the biggest size up to rows cells high that fits text
+// across the canvas with a cell to spare either side.
+func fitBanner(c *Canvas, text string, rows int, colours []Colour) *Sprite {
+	for size := rows * c.CellH; size > c.CellH; size-- {
+		if rasteriseBanner(text, size).W <= c.Width-2*c.CellW {
+			return newBanner(text, size, colours)
+		}
+	}
+	return newBanner(text, c.CellH, colours)
+}
+
+// drawBannerCentre draws a banner across the middle of the canvas with
+// its top at sub-pixel row y.
+func drawBannerCentre(c *Canvas, banner *Sprite, y int) {
+	blit(c, banner, (c.Width-banner.W)/2, y)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v3"
+)
+
+// CastTty passes everything through to the terminal underneath and,
+// while a flight is being cast, keeps a copy of what was written with
+// when it was written so it can be saved as an asciicast v2 file.
+// Headless flights have no real time so the frame, at 60 a second, is
+// used. It is only touched under mu as the screen writes from its own
+// goroutine.
+type CastTty struct {
+	tcell.Tty
+	Path   string
+	mu     sync.Mutex
+	on     bool
+	title  string
+	width  int
+	height int
+	start  time.Time
+	frame  int
+	events [][3]any
+}
+
+// CastHeader is the first line of an asciicast v2 file.
+type CastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title"`
+	Env       map[string]string `json:"env"`
+}
+
+// caster, when set, is the terminal the screen writes through
+var caster *CastTty
+
+func newCastTty(t tcell.Tty, path string) *CastTty {
+	caster = &CastTty{Tty: t, Path: path}
+	return caster
+}
+
+// newCastScreen opens the terminal through a CastTty.
+func newCastScreen(path string) (tcell.Screen, error) {
+	t, err := tcell.NewDevTty()
+	if err != nil {
+		return nil, err
+	}
+	return tcell.NewTerminfoScreenFromTty(newCastTty(t, path))
+}
+
+func (c *CastTty) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	if c.on {
+		c.events = append(c.events, [3]any{c.elapsed(), "o", string(b)})
+	}
+	c.mu.Unlock()
+	return c.Tty.Write(b)
+}
+
+func (c *CastTty) elapsed() float64 {
+	if settings.Headless {
+		return float64(c.frame) / 60
+	}
+	return time.Since(c.start).Seconds()
+}
+
+// begin starts casting a flight to path, the screen should be synced
+// straight after so the cast opens with the whole of it.
+func (c *CastTty) begin(path, title string, width, height int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.on = true
+	c.Path = path
+	c.title = title
+	c.width, c.height = width, height
+	c.start = time.Now()
+	c.frame = 0
+	c.events = nil
+}
+
+// setFrame moves the headless clock on to frame.
+func (c *CastTty) setFrame(frame int) {
+	c.mu.Lock()
+	c.frame = frame
+	c.mu.Unlock()
+}
+
+func (c *CastTty) resize(width, height int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.on {
+		c.events = append(c.events, [3]any{c.elapsed(), "r", fmt.Sprintf("%dx%d", width, height)})
+	}
+}
+
+// end stops casting and writes the header and events out.
+func (c *CastTty) end() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.on = false
+	if len(c.events) == 0 {
+		return
+	}
+	header := CastHeader{
+		Version:   2,
+		Width:     c.width,
+		Height:    c.height,
+		Timestamp: c.start.Unix(),
+		Title:     c.title,
+		Env:       map[string]string{"TERM": os.Getenv("TERM")},
+	}
+	file, err := os.Create(c.Path)
+	if err == nil {
+		out := bufio.NewWriter(file)
+		encoder := json.NewEncoder(out)
+		encoder.SetEscapeHTML(false)
+		err = encoder.Encode(header)
+		for _, event := range c.events {
+			if err != nil {
+				break
+			}
+			err = encoder.Encode(event)
+		}
+		if err == nil {
+			err = out.Flush()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Println("Cast not saved", err)
+		return
+	}
+	log.Println("Cast saved", c.Path, len(c.events), "events")
+}
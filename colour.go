@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v3/color"
+)
+
+// Colour is a 24 bit RGB colour. The opaque bit is set on every real
+// colour so black can still be drawn while 0 means an empty sub-pixel.
+type Colour uint32
+
+const opaque = 1 << 24
+
+func rgb(r, g, b uint8) Colour {
+	return Colour(opaque | uint32(r)<<16 | uint32(g)<<8 | uint32(b))
+}
+
+func (c Colour) rgb() (uint8, uint8, uint8) {
+	return uint8(c >> 16), uint8(c >> 8), uint8(c)
+}
+
+// scale brightens or darkens a colour, 1 leaves it unchanged.
+func (c Colour) scale(f float64) Colour {
+	if c == 0 {
+		return 0
+	}
+	r, g, b := c.rgb()
+	channel := func(v uint8) uint8 {
+		return uint8(math.Max(0, math.Min(255, float64(v)*f)))
+	}
+	return rgb(channel(r), channel(g), channel(b))
+}
+
+// blend mixes from a to b, t of 0 giving a and 1 giving b.
+func blend(a, b Colour, t float64) Colour {
+	t = math.Max(0, math.Min(1, t))
+	ar, ag, ab := a.rgb()
+	br, bg, bb := b.rgb()
+	channel := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t)
+	}
+	return rgb(channel(ar, br), channel(ag, bg), channel(ab, bb))
+}
+
+// toColor gives the tcell colour, tcell fits it to the terminal's
+// palette when true colour isn't available.
+func (c Colour) toColor() color.Color {
+	if c == 0 {
+		return color.Black
+	}
+	return color.NewHexColor(int32(c & 0xffffff))
+}
+
+func brightness(c Colour) int {
+	r, g, b := c.rgb()
+	return int(r) + int(g) + int(b)
+}
+
+// terrainColour shades the ground darker the deeper it is on screen.
+func terrainColour(y, height float64) Colour {
+	return blend(GREEN, DARKGREEN, y/height)
+}
@@ -3,140 +3,339 @@ package main
 import (
 	"log"
 	"math"
+	"os"
+	"strings"
 
 	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
 )
 
-func drawLine(xRunes [][]byte, width, height int, x1, y1, x2, y2 float64, colour byte, orOrxOr bool) {
+// Canvas holds the colour of every sub-pixel, 0 meaning empty. A terminal
+// cell is made up of CellW by CellH sub-pixels depending on the renderer.
+type Canvas struct {
+	Width  int
+	Height int
+	CellW  int
+	CellH  int
+	Pix    [][]Colour
+}
 
-	if x2 < x1 {
-		swap := x1
-		x1 = x2
-		x2 = swap
-	}
-	if y2 < y1 {
-		swap := y1
-		y1 = y2
-		y2 = swap
+func newCanvas(columns, rows, cellW, cellH int) *Canvas {
+	c := &Canvas{
+		Width:  columns * cellW,
+		Height: rows * cellH,
+		CellW:  cellW,
+		CellH:  cellH,
 	}
-	lineWidth := x2 - x1
-	lineHeight := y2 - y1
-	stepY := lineHeight / lineWidth
-	stepX := 1.0
-	if lineHeight > lineWidth {
-		stepX = lineWidth / lineHeight
-		stepY = 1.0
+	c.Pix = make([][]Colour, c.Height)
+	for y := range c.Pix {
+		c.Pix[y] = make([]Colour, c.Width)
 	}
-	if x1 == x2 {
-		stepX = 0
+	return c
+}
+
+func (c *Canvas) inside(x, y int) bool {
+	return x >= 0 && x < c.Width && y >= 0 && y < c.Height
+}
+
+func (c *Canvas) set(x, y int, colour Colour) {
+	if c.inside(x, y) {
+		c.Pix[y][x] = colour
 	}
-	if y1 == y2 {
-		stepY = 0
+}
+
+func (c *Canvas) get(x, y int) Colour {
+	if c.inside(x, y) {
+		return c.Pix[y][x]
 	}
+	return 0
+}
 
-	y := y1
-	x := x1
-	var bit byte = 0
+// copyFrom resets the canvas to the contents of another of the same size.
+func (c *Canvas) copyFrom(from *Canvas) {
+	for y := range c.Pix {
+		copy(c.Pix[y], from.Pix[y])
+	}
+}
 
-	oldYi, oldXi := -9999, -9999
-	var oldBit byte = 255
+func (c *Canvas) clear() {
+	for y := range c.Pix {
+		clear(c.Pix[y])
+	}
+}
 
-	for {
-		if x >= x2 && y >= y2 || x <= 0 || x >= float64(width*2) || y <= 0 || y >= float64(height*2) {
-			break
+// overlay copies the set sub-pixels of another canvas of the same size
+// on top, leaving the rest showing through.
+func (c *Canvas) overlay(from *Canvas) {
+	for y := range c.Pix {
+		for x, colour := range from.Pix[y] {
+			if colour != 0 {
+				c.Pix[y][x] = colour
+			}
 		}
-		yi := int((y) / 2)
-		xi := int((x) / 2)
+	}
+}
 
-		Debug("oldBit %x xi %d yi %d\n", oldBit, xi, yi)
-		if xRunes[yi] == nil {
-			xRunes[yi] = make([]byte, width)
-		}
+// Renderer turns the sub-pixels of one cell into a glyph. The bits passed
+// to Glyph are the set sub-pixels in row order, bit 0 being top left.
+// Supported, when set, reports whether the terminal can show the glyphs
+// and if not the Fallback renderer is tried instead. Draw replaces the
+// glyph lookup for renderers that need more than one colour per cell.
+type Renderer struct {
+	Name      string
+	CellW     int
+	CellH     int
+	Glyph     func(bits int) rune
+	Supported func(s tcell.Screen) bool
+	Fallback  string
+	Draw      func(c *Canvas, s tcell.Screen)
+}
 
-		xx := int(math.Mod(x, 2))
-		yy := int(math.Mod(y, 2))
+var renderers = []*Renderer{
+	{Name: "Quadrant", CellW: 2, CellH: 2, Glyph: quadrantGlyph, Supported: unicodeSupported, Fallback: "ASCII"},
+	{Name: "Braille", CellW: 2, CellH: 4, Glyph: brailleGlyph, Supported: unicodeSupported, Fallback: "ASCII"},
+	{Name: "Sextant", CellW: 2, CellH: 3, Glyph: sextantGlyph, Supported: sextantSupported, Fallback: "Quadrant"},
+	{Name: "Half Block", CellW: 1, CellH: 2, Draw: drawHalfBlocks, Supported: unicodeSupported, Fallback: "ASCII"},
+	{Name: "ASCII", CellW: 2, CellH: 2, Glyph: asciiGlyph},
+}
 
-		if xx == 0 && yy == 0 {
-			bit = 1
-		}
-		if xx == 1 && yy == 0 {
-			bit = 2
+func findRenderer(name string) *Renderer {
+	for _, r := range renderers {
+		if strings.EqualFold(r.Name, name) {
+			return r
 		}
-		if xx == 0 && yy == 1 {
-			bit = 4
+	}
+	return renderers[0]
+}
+
+// asciiTerminal is set when the terminal can only show plain ASCII
+var asciiTerminal bool
+
+// asciiMode is true when everything is drawn in plain ASCII, asked for
+// with -ascii, picked as the renderer or forced by the terminal.
+func asciiMode() bool {
+	return settings.ASCII || asciiTerminal || findRenderer(settings.Renderer).Name == "ASCII"
+}
+
+// chooseRenderer finds the named renderer, following the fallbacks when
+// the terminal can't display it. ASCII mode overrides everything.
+func chooseRenderer(s tcell.Screen, name string) *Renderer {
+	if settings.ASCII || asciiTerminal {
+		return findRenderer("ASCII")
+	}
+	r := findRenderer(name)
+	for r.Supported != nil && !r.Supported(s) && r.Fallback != "" {
+		log.Printf("%s display not supported by terminal, trying %s\n", r.Name, r.Fallback)
+		r = findRenderer(r.Fallback)
+	}
+	return r
+}
+
+func quadrantGlyph(bits int) rune {
+	switch bits {
+	case 0:
+		return 0x0020
+	case 1:
+		return 0x2598 // top left
+	case 2:
+		return 0x259d // top right
+	case 3:
+		return 0x2580 // top half
+	case 4:
+		return 0x2596 // lower left
+	case 5:
+		return 0x258c // left half
+	case 6:
+		return 0x259e
+	case 7:
+		return 0x259b
+	case 8:
+		return 0x2597 // lower right
+	case 9:
+		return 0x259a
+	case 10:
+		return 0x2590 // right half
+	case 11:
+		return 0x259c
+	case 12:
+		return 0x2584 // lower half
+	case 13:
+		return 0x2599
+	case 14:
+		return 0x259f
+	}
+	return 0x2588
+}
+
+// braille dots are numbered down the left column then the right, with
+// the bottom row added later as dots 7 and 8.
+var brailleDots = [8]int{0, 3, 1, 4, 2, 5, 6, 7}
+
+func brailleGlyph(bits int) rune {
+	if bits == 0 {
+		return ' '
+	}
+	var dots rune
+	for i, dot := range brailleDots {
+		if bits&(1<<i) != 0 {
+			dots |= 1 << dot
 		}
-		if xx == 1 && yy == 1 {
-			bit = 8
+	}
+	return 0x2800 + dots
+}
+
+// asciiGlyphs draw the quadrant patterns as slopes and lines using
+// characters any console can show.
+var asciiGlyphs = [16]rune{
+	' ',  // empty
+	'`',  // top left
+	'\'', // top right
+	'-',  // top half
+	'.',  // lower left
+	'|',  // left half
+	'/',  // top right, lower left
+	'+',
+	'.',  // lower right
+	'\\', // top left, lower right
+	'|',  // right half
+	'+',
+	'_', // lower half
+	'+',
+	'+',
+	'#',
+}
+
+func asciiGlyph(bits int) rune {
+	return asciiGlyphs[bits&15]
+}
+
+// unicodeSupported is false when tcell has fallen back to ASCII because
+// the terminal's character set isn't UTF-8.
+func unicodeSupported(s tcell.Screen) bool {
+	if IsWASM {
+		return true
+	}
+	return strings.EqualFold(s.CharacterSet(), "UTF-8")
+}
+
+// sextants arrived in Unicode 13 and skip the patterns that already
+// exist as blocks, the empty cell, the left and right halves and full.
+func sextantGlyph(bits int) rune {
+	switch bits {
+	case 0:
+		return ' '
+	case 21:
+		return 0x258c // left half
+	case 42:
+		return 0x2590 // right half
+	case 63:
+		return 0x2588
+	}
+	index := bits - 1
+	if bits > 21 {
+		index--
+	}
+	if bits > 42 {
+		index--
+	}
+	return 0x1fb00 + rune(index)
+}
+
+// terminals known to ship fonts with the legacy computing symbols
+var sextantTerminals = []string{"kitty", "wezterm", "foot", "ghostty", "contour", "konsole", "iterm"}
+
+func sextantSupported(s tcell.Screen) bool {
+	switch os.Getenv("GOLUNAR_SEXTANT") {
+	case "1":
+		return true
+	case "0":
+		return false
+	}
+	if !unicodeSupported(s) {
+		return false
+	}
+	if IsWASM {
+		return true
+	}
+	name, _ := s.Terminal()
+	candidates := []string{name, os.Getenv("TERM_PROGRAM"), os.Getenv("TERM")}
+	for _, candidate := range candidates {
+		for _, known := range sextantTerminals {
+			if strings.Contains(strings.ToLower(candidate), known) {
+				return true
+			}
 		}
-		Debug("b4 rune %x at %f/%f  bit %x\n", xRunes[yi][xi], x, y, bit)
-		if bit != oldBit || xi != oldXi || yi != oldYi {
-			if orOrxOr {
-				xRunes[yi][xi] = xRunes[yi][xi] | bit | colour
-			} else {
-				xRunes[yi][xi] = xRunes[yi][xi] ^ bit
+	}
+	return false
+}
+
+func drawCanvasToScreen(c *Canvas, s tcell.Screen, r *Renderer) {
+	if r.Draw != nil {
+		r.Draw(c, s)
+		return
+	}
+	var colours [8]Colour
+	for yi := 0; yi < c.Height/c.CellH; yi++ {
+		for xi := 0; xi < c.Width/c.CellW; xi++ {
+			bits := 0
+			set := 0
+			for py := 0; py < c.CellH; py++ {
+				row := c.Pix[yi*c.CellH+py]
+				for px := 0; px < c.CellW; px++ {
+					colour := row[xi*c.CellW+px]
+					if colour != 0 {
+						bits |= 1 << (py*c.CellW + px)
+						colours[set] = colour
+						set++
+					}
+				}
 			}
+			style := tcell.StyleDefault.Foreground(cellColour(colours[:set]).toColor()).Background(color.Black)
+			s.SetContent(xi, yi, r.Glyph(bits), nil, style)
 		}
-		Debug("rune %x at %f/%f   bit %x\n", xRunes[yi][xi], x, y, bit)
-
-		oldXi = xi
-		oldYi = yi
-		oldBit = bit
-
-		y = y + stepY
-		x = x + stepX
-	}
-	// log.Printf("complete\n")
-}
-
-func drawRunesToScreen(runes [][]byte, s tcell.Screen, styles [4]tcell.Style) {
-	var gl rune = 0
-	for yi := range runes {
-		for xi := range runes[yi] {
-			raw := runes[yi][xi]
-			bits := raw & 0x0f
-			colour := raw >> 4
-
-			switch bits {
-			case 0:
-				gl = 0x0020
-			case 1:
-				gl = 0x2598 // top left
-			case 2:
-				gl = 0x259d // top right
-			case 3:
-				gl = 0x2580 // top half
-			case 4:
-				gl = 0x2596 // lower left
-			case 5:
-				gl = 0x258c // left half
-			case 6:
-				gl = 0x259e
-			case 7:
-				gl = 0x259b
-			case 8:
-				gl = 0x2597 // lower right
-			case 9:
-				gl = 0x259a
-			case 10:
-				gl = 0x2590 // right half
-			case 11:
-				gl = 0x259c
-			case 12:
-				gl = 0x2584 // lower half
-			case 13:
-				gl = 0x2599
-			case 14:
-				gl = 0x259f
-			case 15:
-				gl = 0x2588
+	}
+}
+
+// drawHalfBlocks gives each sub-pixel its own colour, the upper half
+// block is drawn in the top colour over a background of the bottom one.
+func drawHalfBlocks(c *Canvas, s tcell.Screen) {
+	for yi := 0; yi < c.Height/2; yi++ {
+		for xi := 0; xi < c.Width; xi++ {
+			top := c.Pix[yi*2][xi]
+			bottom := c.Pix[yi*2+1][xi]
+			style := tcell.StyleDefault.Foreground(top.toColor()).Background(bottom.toColor())
+			switch {
+			case top == 0 && bottom == 0:
+				s.SetContent(xi, yi, ' ', nil, style)
+			case top == bottom:
+				s.SetContent(xi, yi, 0x2588, nil, style)
 			default:
-				log.Printf("Unexpected %x\n", runes[yi][xi])
+				s.SetContent(xi, yi, 0x2580, nil, style)
 			}
-			s.SetContent(xi, yi, gl, nil, styles[colour])
 		}
 	}
 }
 
+// cellColour picks the most common colour of a cell, ties going to the
+// brighter colour so pads and the ship win over the shaded terrain.
+func cellColour(colours []Colour) Colour {
+	var best Colour
+	bestCount := 0
+	for _, colour := range colours {
+		count := 0
+		for _, other := range colours {
+			if other == colour {
+				count++
+			}
+		}
+		if count > bestCount || count == bestCount && brightness(colour) > brightness(best) {
+			best = colour
+			bestCount = count
+		}
+	}
+	return best
+}
+
 func drawText(s tcell.Screen, x1, y1, x2, y2 int, style tcell.Style, text string) {
 	row := y1
 	col := x1
@@ -158,18 +357,100 @@ func drawText(s tcell.Screen, x1, y1, x2, y2 int, style tcell.Style, text string
 	}
 }
 
-func drawShip(xRunes [][]byte, width, height int, xx, yy float64, orOrxOr bool) {
+// drawShip puts the sprite in the canvas, in ASCII mode the ship is
+// drawn over the screen by drawASCIIShip instead.
+func drawShip(c *Canvas, ship *ShipDesign, xx, yy float64) {
+	if asciiMode() {
+		return
+	}
+	blit(c, ship.Sprite, int(xx), int(yy))
+}
+
+// drawASCIIShip draws the ship's ASCII art with its bottom row on the
+// ship's cell and a flame under each nozzle, longer the higher the
+// throttle from 0 to 1.
+func drawASCIIShip(s tcell.Screen, c *Canvas, ship *ShipDesign, xx, yy, throttle float64) {
+	shipStyle := tcell.StyleDefault.Foreground(color.White).Background(color.Black)
+	cx, cy := int(xx)/c.CellW, int(yy)/c.CellH
+	for i, row := range ship.ASCII {
+		y := cy - (len(ship.ASCII) - 1 - i)
+		x := cx - len(row)/2
+		for j, ch := range row {
+			if ch != ' ' {
+				s.SetContent(x+j, y, ch, nil, shipStyle)
+			}
+		}
+	}
+	if throttle <= 0 {
+		return
+	}
+	flame := []rune{'v'}
+	if throttle > 0.5 {
+		flame = []rune{'V', '\''}
+	}
+	flameColours := []color.Color{color.Yellow, color.Red}
+	for _, nozzle := range ship.Nozzles {
+		x := cx + int(math.Round(nozzle.X/float64(c.CellW)))
+		for i, ch := range flame {
+			s.SetContent(x, cy+1+i, ch, nil, tcell.StyleDefault.Foreground(flameColours[i]).Background(color.Black))
+		}
+	}
+}
 
-	x := float64(int(xx))
-	y := float64(int(yy))
+// drawBox clears a box and draws its border, with block elements or
+// plain ASCII depending on the terminal.
+func drawBox(s tcell.Screen, boxX, boxY, boxWidth, boxHeight int, style tcell.Style) {
+	for row := 0; row < boxHeight; row++ {
+		for col := 0; col < boxWidth; col++ {
+			ch := ' '
 
-	var colour byte = YELLOW
+			if asciiMode() {
+				if row == 0 || row == boxHeight-1 {
+					ch = '-'
+				}
+				if col == 0 || col == boxWidth-1 {
+					ch = '|'
+				}
+				if (row == 0 || row == boxHeight-1) && (col == 0 || col == boxWidth-1) {
+					ch = '+'
+				}
+				s.SetContent(boxX+col, boxY+row, ch, nil, style)
+				continue
+			}
 
-	drawLine(xRunes, width, height, x-1, y-1, x-1, y+1, colour, orOrxOr)
-	drawLine(xRunes, width, height, x+1, y-1, x+1, y+1, colour, orOrxOr)
-	drawLine(xRunes, width, height, x, y-1, x+1, y-1, colour, orOrxOr)
-	drawLine(xRunes, width, height, x, y-2, x+1, y-2, colour, orOrxOr)
+			if row == 0 {
+				ch = 0x2584
+			}
+			if row == boxHeight-1 {
+				ch = 0x2584
+			}
+			if col == 0 {
+				ch = 0x2590
+			}
+			if col == boxWidth-1 {
+				ch = 0x258C
+			}
+			if row == 0 && col == 0 {
+				ch = 0x2597
+			} else if row == 0 && col == boxWidth-1 {
+				ch = 0x2596
+			} else if row == boxHeight-1 && col == 0 {
+				ch = 0x2590
+			} else if row == boxHeight-1 && col == boxWidth-1 {
+				ch = 0x258c
+			}
+			s.SetContent(boxX+col, boxY+row, ch, nil, style)
+		}
+	}
+}
 
+// glyph picks the ASCII stand in for a symbol when block elements and
+// the like can't be shown.
+func glyph(unicode, ascii rune) rune {
+	if asciiMode() {
+		return ascii
+	}
+	return unicode
 }
 
 func drawTextCentre(s tcell.Screen, width, y int, style tcell.Style, text string) {
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	imagecolor "image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/vt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// exported images draw every terminal cell this many pixels across and
+// down, about the shape of a terminal font
+const exportCellW = 8
+const exportCellH = 16
+
+// flights counts the flights flown this session, the first saves to the
+// files named with -cast, -gif and -record and later ones number theirs
+var flights = 0
+
+// flightPath is path for the flight being flown, the second flight of a
+// session saving flight.gif as flight-2.gif so it keeps the first.
+func flightPath(path string) string {
+	if flights <= 1 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), flights, ext)
+}
+
+// exportName gives the path for a capture of level without extension.
+func exportName(level string) string {
+	slug := strings.ToLower(strings.Join(strings.Fields(level), "-"))
+	name := fmt.Sprintf("golunar-%s-%s", slug, time.Now().Format("20060102-150405"))
+	return filepath.Join(settings.ExportDir, name)
+}
+
+// exportFrame saves the screen as a PNG and an ANSI text file and
+// returns their paths.
+func exportFrame(s tcell.Screen, c *Canvas, r *Renderer, level string) []string {
+	name := exportName(level)
+	saved := make([]string, 0)
+	img := frameImage(s, c, r, exportCellW, exportCellH)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		log.Println("PNG not encoded", err)
+	} else if err := os.WriteFile(name+".png", buf.Bytes(), 0644); err != nil {
+		log.Println("PNG not saved", err)
+	} else {
+		saved = append(saved, name+".png")
+	}
+	if err := os.WriteFile(name+".ans", []byte(frameANSI(s)), 0644); err != nil {
+		log.Println("ANSI not saved", err)
+	} else {
+		saved = append(saved, name+".ans")
+	}
+	log.Println("Exported frame", saved)
+	return saved
+}
+
+func styleColours(style tcell.Style) (Colour, Colour) {
+	fg, bg := WHITE, rgb(0, 0, 0)
+	if hex := style.GetForeground().Hex(); hex >= 0 {
+		fg = Colour(opaque | hex)
+	}
+	if hex := style.GetBackground().Hex(); hex >= 0 {
+		bg = Colour(opaque | hex)
+	}
+	return fg, bg
+}
+
+func (colour Colour) rgba() imagecolor.RGBA {
+	r, g, b := colour.rgb()
+	return imagecolor.RGBA{R: r, G: g, B: b, A: 0xff}
+}
+
+// canvasRune is the glyph the renderer draws for a cell of the canvas,
+// a cell showing anything else has had text drawn over it.
+func canvasRune(c *Canvas, r *Renderer, xi, yi int) rune {
+	if r.Draw != nil {
+		top := c.get(xi, yi*2)
+		bottom := c.get(xi, yi*2+1)
+		switch {
+		case top == 0 && bottom == 0:
+			return ' '
+		case top == bottom:
+			return 0x2588
+		}
+		return 0x2580
+	}
+	bits := 0
+	for py := 0; py < c.CellH; py++ {
+		for px := 0; px < c.CellW; px++ {
+			if c.get(xi*c.CellW+px, yi*c.CellH+py) != 0 {
+				bits |= 1 << (py*c.CellW + px)
+			}
+		}
+	}
+	return r.Glyph(bits)
+}
+
+// frameImage draws the canvas at full sub-pixel resolution, in every
+// sub-pixel's own colour, and the text over it with the banner font.
+// Each cell is cellW by cellH pixels.
+func frameImage(s tcell.Screen, c *Canvas, r *Renderer, cellW, cellH int) *image.RGBA {
+	width, height := s.Size()
+	img := image.NewRGBA(image.Rect(0, 0, width*cellW, height*cellH))
+	face := bannerFace(cellH - 3)
+	for yi := 0; yi < height; yi++ {
+		for xi := 0; xi < width; xi++ {
+			str, style, _ := s.Get(xi, yi)
+			ch := ' '
+			if str != "" {
+				ch = []rune(str)[0]
+			}
+			x0, y0 := xi*cellW, yi*cellH
+			if ch == canvasRune(c, r, xi, yi) {
+				for py := 0; py < cellH; py++ {
+					for px := 0; px < cellW; px++ {
+						colour := c.get(xi*c.CellW+px*c.CellW/cellW, yi*c.CellH+py*c.CellH/cellH)
+						if colour == 0 {
+							colour = rgb(0, 0, 0)
+						}
+						img.SetRGBA(x0+px, y0+py, colour.rgba())
+					}
+				}
+				continue
+			}
+			fg, bg := styleColours(style)
+			for py := 0; py < cellH; py++ {
+				for px := 0; px < cellW; px++ {
+					colour := bg
+					switch ch {
+					case 0x2588:
+						colour = fg
+					case 0x2591:
+						if (px+py)%4 == 0 {
+							colour = fg
+						}
+					case 0x25A0:
+						if px > 0 && px < cellW-1 && py > cellH/4 && py < cellH*3/4 {
+							colour = fg
+						}
+					}
+					img.SetRGBA(x0+px, y0+py, colour.rgba())
+				}
+			}
+			if ch == ' ' || ch == 0x2588 || ch == 0x2591 || ch == 0x25A0 || face == nil {
+				continue
+			}
+			if mask := glyphMask(face, ch, cellW, cellH); mask != nil {
+				draw.DrawMask(img, image.Rect(x0, y0, x0+cellW, y0+cellH), image.NewUniform(fg.rgba()), image.Point{}, mask, image.Point{}, draw.Over)
+			}
+		}
+	}
+	return img
+}
+
+type glyphKey struct {
+	ch           rune
+	cellW, cellH int
+}
+
+// glyphMasks hold each character rasterised once for a cell size, so
+// frames only have to colour them in. Nil if the font hasn't got it.
+var glyphMasks = map[glyphKey]*image.Alpha{}
+
+func glyphMask(face font.Face, ch rune, cellW, cellH int) *image.Alpha {
+	key := glyphKey{ch, cellW, cellH}
+	if mask, ok := glyphMasks[key]; ok {
+		return mask
+	}
+	var mask *image.Alpha
+	if _, ok := face.GlyphAdvance(ch); ok {
+		mask = image.NewAlpha(image.Rect(0, 0, cellW, cellH))
+		drawer := font.Drawer{
+			Dst:  mask,
+			Src:  image.Opaque,
+			Face: face,
+			Dot:  fixed.P(1, cellH-4),
+		}
+		drawer.DrawString(string(ch))
+	}
+	glyphMasks[key] = mask
+	return mask
+}
+
+// frameANSI writes the screen out with 24 bit colour escapes so it can
+// be shown again with cat.
+func frameANSI(s tcell.Screen) string {
+	var b strings.Builder
+	width, height := s.Size()
+	for y := 0; y < height; y++ {
+		var lastFg, lastBg Colour
+		for x := 0; x < width; x++ {
+			str, style, w := s.Get(x, y)
+			if str == "" {
+				str = " "
+			}
+			fg, bg := styleColours(style)
+			if fg != lastFg {
+				r, g, bl := fg.rgb()
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm", r, g, bl)
+				lastFg = fg
+			}
+			if bg != lastBg {
+				r, g, bl := bg.rgb()
+				fmt.Fprintf(&b, "\x1b[48;2;%d;%d;%dm", r, g, bl)
+				lastBg = bg
+			}
+			b.WriteString(str)
+			if w > 1 {
+				x += w - 1
+			}
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return b.String()
+}
+
+// newHeadlessScreen gives a screen backed by an in memory terminal so a
+// frame can be exported without a terminal attached.
+func newHeadlessScreen(width, height int) (tcell.Screen, error) {
+	term := vt.NewMockTerm(vt.MockOptSize{X: vt.Col(width), Y: vt.Row(height)})
+	if settings.Cast != "" {
+		return tcell.NewTerminfoScreenFromTty(newCastTty(term, settings.Cast))
+	}
+	return tcell.NewTerminfoScreenFromTty(term)
+}
+
+// isExportKey is the key that saves a screenshot during a flight. The
+// browser has no files to save to so there it is never pressed.
+func isExportKey(ev *tcell.EventKey) bool {
+	if IsWASM {
+		return false
+	}
+	return ev.Key() == tcell.KeyF12 || ev.Str() == "p" || ev.Str() == "P"
+}
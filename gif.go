@@ -0,0 +1,136 @@
+package main
+
+import (
+	"image"
+	imagecolor "image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/gdamore/tcell/v3"
+)
+
+var gifPalettes = []string{"adaptive", "plan9", "websafe"}
+
+// maxGIFFrames is as many frames as a GIF keeps, past it every other
+// one is dropped and the rest shown for twice as long so a long flight
+// doesn't fill memory.
+const maxGIFFrames = 500
+
+// GIFRecorder collects frames of a flight for an animated GIF. Scale 1
+// draws each cell 4 by 8 pixels, Skip keeps every Skip'th frame and
+// Palette is one of gifPalettes.
+type GIFRecorder struct {
+	Path    string
+	Scale   int
+	Skip    int
+	Palette string
+	frame   int
+	next    int
+	anim    gif.GIF
+}
+
+func newGIFRecorder(path string) *GIFRecorder {
+	return &GIFRecorder{
+		Path:    path,
+		Scale:   max(1, settings.GIFScale),
+		Skip:    max(1, settings.GIFSkip),
+		Palette: settings.GIFPalette,
+	}
+}
+
+// adaptivePalette picks the most used colours in the image, the game
+// has few enough that they usually all fit.
+func adaptivePalette(img *image.RGBA) imagecolor.Palette {
+	counts := map[imagecolor.RGBA]int{}
+	for i := 0; i < len(img.Pix); i += 4 {
+		counts[imagecolor.RGBA{R: img.Pix[i], G: img.Pix[i+1], B: img.Pix[i+2], A: 0xff}]++
+	}
+	colours := make([]imagecolor.RGBA, 0, len(counts))
+	for colour := range counts {
+		colours = append(colours, colour)
+	}
+	sort.Slice(colours, func(i, j int) bool {
+		return counts[colours[i]] > counts[colours[j]]
+	})
+	p := imagecolor.Palette{}
+	for _, colour := range colours[:min(256, len(colours))] {
+		p = append(p, colour)
+	}
+	return p
+}
+
+func (g *GIFRecorder) paletted(img *image.RGBA) *image.Paletted {
+	out := image.NewPaletted(img.Bounds(), nil)
+	switch g.Palette {
+	case "plan9", "websafe":
+		out.Palette = palette.Plan9
+		if g.Palette == "websafe" {
+			out.Palette = palette.WebSafe
+		}
+		draw.FloydSteinberg.Draw(out, out.Bounds(), img, image.Point{})
+	default:
+		// the game's colours nearly all fit so dithering would only
+		// add noise
+		out.Palette = adaptivePalette(img)
+		draw.Draw(out, out.Bounds(), img, image.Point{}, draw.Src)
+	}
+	return out
+}
+
+// capture adds the screen as it is now, if it is a frame being kept.
+func (g *GIFRecorder) capture(s tcell.Screen, c *Canvas, r *Renderer) {
+	g.frame++
+	if g.frame < g.next {
+		return
+	}
+	if len(g.anim.Image) >= maxGIFFrames {
+		g.thin()
+	}
+	g.next = g.frame + g.Skip
+	img := frameImage(s, c, r, 4*g.Scale, 8*g.Scale)
+	g.anim.Image = append(g.anim.Image, g.paletted(img))
+	// the game runs at 60 frames a second, GIF delays are hundredths
+	g.anim.Delay = append(g.anim.Delay, max(2, g.Skip*100/60))
+}
+
+// thin drops every other frame, each one kept takes the time of the one
+// dropped after it, and halves how often frames are kept from now on.
+func (g *GIFRecorder) thin() {
+	kept := 0
+	for i := 0; i < len(g.anim.Image); i += 2 {
+		delay := g.anim.Delay[i]
+		if i+1 < len(g.anim.Delay) {
+			delay += g.anim.Delay[i+1]
+		}
+		g.anim.Image[kept] = g.anim.Image[i]
+		g.anim.Delay[kept] = delay
+		kept++
+	}
+	clear(g.anim.Image[kept:])
+	g.anim.Image = g.anim.Image[:kept]
+	g.anim.Delay = g.anim.Delay[:kept]
+	g.Skip *= 2
+	log.Println("GIF thinned to", kept, "frames, keeping every", g.Skip)
+}
+
+func (g *GIFRecorder) save() {
+	if len(g.anim.Image) == 0 {
+		return
+	}
+	file, err := os.Create(g.Path)
+	if err == nil {
+		err = gif.EncodeAll(file, &g.anim)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Println("GIF not saved", err)
+		return
+	}
+	log.Println("GIF saved", g.Path, len(g.anim.Image), "frames")
+}
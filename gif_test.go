@@ -0,0 +1,68 @@
+package main
+
+import (
+	"image"
+	"image/color/palette"
+	"testing"
+)
+
+func totalDelay(g *GIFRecorder) int {
+	total := 0
+	for _, delay := range g.anim.Delay {
+		total += delay
+	}
+	return total
+}
+
+func TestGIFThin(t *testing.T) {
+	for _, frames := range []int{1, 2, 7, 10} {
+		g := &GIFRecorder{Skip: 4}
+		for i := 0; i < frames; i++ {
+			g.anim.Image = append(g.anim.Image, image.NewPaletted(image.Rect(0, 0, 1, 1), palette.Plan9))
+			g.anim.Delay = append(g.anim.Delay, 6+i%2)
+		}
+		before := totalDelay(g)
+		first := g.anim.Image[0]
+		g.thin()
+		if want := (frames + 1) / 2; len(g.anim.Image) != want || len(g.anim.Delay) != want {
+			t.Errorf("%d frames thinned to %d images and %d delays, want %d", frames, len(g.anim.Image), len(g.anim.Delay), want)
+		}
+		if totalDelay(g) != before {
+			t.Errorf("%d frames thinned from %d hundredths to %d", frames, before, totalDelay(g))
+		}
+		if g.anim.Image[0] != first {
+			t.Errorf("%d frames thinned lost the first frame", frames)
+		}
+		if g.Skip != 8 {
+			t.Errorf("skip %d after thinning, want 8", g.Skip)
+		}
+	}
+}
+
+func TestGIFCaptureCapped(t *testing.T) {
+	s, err := newHeadlessScreen(4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Fini()
+	r := findRenderer("Quadrant")
+	c := newCanvas(4, 2, r.CellW, r.CellH)
+
+	g := &GIFRecorder{Scale: 1, Skip: 1, Palette: "plan9"}
+	const frames = maxGIFFrames * 5
+	for i := 0; i < frames; i++ {
+		g.capture(s, c, r)
+	}
+	if len(g.anim.Image) > maxGIFFrames || len(g.anim.Image) != len(g.anim.Delay) {
+		t.Errorf("%d images and %d delays kept, at most %d wanted", len(g.anim.Image), len(g.anim.Delay), maxGIFFrames)
+	}
+	// thinning keeps the GIF as long as the flight, give or take the
+	// delays being rounded to hundredths
+	want := frames * 100 / 60
+	if got := totalDelay(g); got < want*9/10 || got > want*11/10 {
+		t.Errorf("GIF lasts %d hundredths, flight was %d", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+// blitScaled draws a sprite with every pixel as a block of scale by
+// scale sub-pixels, used to show ships large in the hangar.
+func blitScaled(c *Canvas, sprite *Sprite, x, y, scale int) {
+	for sy := 0; sy < sprite.H; sy++ {
+		for sx := 0; sx < sprite.W; sx++ {
+			colour := sprite.at(sx, sy)
+			if colour == 0 {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					c.set(x+sx*scale+dx, y+sy*scale+dy, colour)
+				}
+			}
+		}
+	}
+}
+
+func statBar(value, most float64) string {
+	const barWidth = 10
+	filled := int(value/most*barWidth + 0.5)
+	if filled > barWidth {
+		filled = barWidth
+	}
+	if asciiMode() {
+		return strings.Repeat("#", filled) + strings.Repeat(".", barWidth-filled)
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+}
+
+func displayHangar(s tcell.Screen, selected int) {
+	styleTitle := tcell.StyleDefault.Foreground(color.Yellow).Background(color.Black)
+	styleNormal := tcell.StyleDefault.Foreground(color.White).Background(color.Black)
+	styleSelected := tcell.StyleDefault.Foreground(color.Black).Background(color.Green)
+
+	renderer := chooseRenderer(s, settings.Renderer)
+	width, height := s.Size()
+	ship := ships[selected]
+
+	// the preview goes on the right half of the screen, as big as fits
+	preview := newCanvas(width, height, renderer.CellW, renderer.CellH)
+	scale := min(preview.Width/2/ship.Sprite.W, preview.Height/2/ship.Sprite.H)
+	scale = max(1, min(scale, 4*renderer.CellH))
+	x := preview.Width*3/4 - ship.Sprite.W*scale/2
+	y := preview.Height/2 - ship.Sprite.H*scale/2
+	blitScaled(preview, ship.Sprite, x, y, scale)
+	drawCanvasToScreen(preview, s, renderer)
+
+	var most ShipDesign
+	for _, other := range ships {
+		most.Mass = max(most.Mass, other.Mass)
+		most.Thrust = max(most.Thrust, other.Thrust)
+		most.FuelCapacity = max(most.FuelCapacity, other.FuelCapacity)
+		most.RCSPower = max(most.RCSPower, other.RCSPower)
+	}
+
+	row := 2
+	drawText(s, 2, row, width/2, row, styleTitle, "Hangar, choose your ship")
+	row += 2
+	for i, other := range ships {
+		style := styleNormal
+		if i == selected {
+			style = styleSelected
+		}
+		drawText(s, 4, row, width/2, row, style, " "+other.Name+" ")
+		row++
+	}
+	row++
+	drawText(s, 2, row, width/2, row, styleNormal, ship.Description)
+	row += 2
+	stats := []struct {
+		label      string
+		value, top float64
+	}{
+		{"Mass", ship.Mass, most.Mass},
+		{"Thrust", ship.Thrust, most.Thrust},
+		{"Fuel", ship.FuelCapacity, most.FuelCapacity},
+		{"RCS", ship.RCSPower, most.RCSPower},
+	}
+	for _, stat := range stats {
+		drawText(s, 2, row, width/2, row, styleNormal, fmt.Sprintf("%-7s %s %5.1f", stat.label, statBar(stat.value, stat.top), stat.value))
+		row++
+	}
+	row++
+	drawText(s, 2, row, width/2, row, styleNormal, "Enter to launch, Escape to go back")
+}
+
+// runHangar lets the pilot pick a ship before a flight, it reports false
+// if they backed out to the menu.
+func runHangar(s tcell.Screen) (*ShipDesign, bool) {
+	selected := 0
+	for i, ship := range ships {
+		if ship.Name == settings.Ship {
+			selected = i
+		}
+	}
+
+	s.Clear()
+	for {
+		displayHangar(s, selected)
+		s.Show()
+
+		ev := <-s.EventQ()
+		switch ev := ev.(type) {
+		case *tcell.EventResize:
+			s.Sync()
+			s.Clear()
+		case *tcell.EventKey:
+			switch ev.Key() {
+			case tcell.KeyUp, tcell.KeyLeft:
+				selected--
+				if selected < 0 {
+					selected = len(ships) - 1
+				}
+				playSound(SoundMenuMove)
+			case tcell.KeyDown, tcell.KeyRight:
+				selected++
+				if selected >= len(ships) {
+					selected = 0
+				}
+				playSound(SoundMenuMove)
+			case tcell.KeyEnter:
+				settings.Ship = ships[selected].Name
+				s.Clear()
+				return ships[selected], true
+			case tcell.KeyEscape:
+				s.Clear()
+				return nil, false
+			}
+		}
+	}
+}
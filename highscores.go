@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+// maxHighScores is how many entries each table keeps
+const maxHighScores = 10
+
+var difficultyNames = []string{"Easy", "Hard"}
+
+// HighScore is one entry in a table, Speed is the landing or docking
+// speed as shown on the instruments.
+type HighScore struct {
+	Name  string
+	Score float64
+	Date  time.Time
+	Fuel  float64
+	Speed float64
+}
+
+// HighScores has a table for every level and one for each difficulty
+// taking the best flights from all of its levels.
+type HighScores struct {
+	Levels       map[string][]HighScore
+	Difficulties map[string][]HighScore
+}
+
+var highScores = HighScores{
+	Levels:       map[string][]HighScore{},
+	Difficulties: map[string][]HighScore{},
+}
+
+func difficultyName(difficulty int) string {
+	if difficulty >= 0 && difficulty < len(difficultyNames) {
+		return difficultyNames[difficulty]
+	}
+	return fmt.Sprint(difficulty)
+}
+
+func loadHighScores() {
+	data, err := readStore("highscores")
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &highScores)
+	}
+	if err != nil {
+		log.Println("High scores not loaded", err)
+		return
+	}
+	if highScores.Levels == nil {
+		highScores.Levels = map[string][]HighScore{}
+	}
+	if highScores.Difficulties == nil {
+		highScores.Difficulties = map[string][]HighScore{}
+	}
+}
+
+func saveHighScores() {
+	data, err := json.MarshalIndent(highScores, "", "  ")
+	if err == nil {
+		err = writeStore("highscores", data)
+	}
+	if err != nil {
+		log.Println("High scores not saved", err)
+	}
+}
+
+// scoreRank is where a score would go in a table, -1 if it wouldn't.
+func scoreRank(table []HighScore, score float64) int {
+	rank := sort.Search(len(table), func(i int) bool {
+		return table[i].Score < score
+	})
+	if rank >= maxHighScores {
+		return -1
+	}
+	return rank
+}
+
+// highScoreRank is where a score would go in the level's table.
+func highScoreRank(level Level, score float64) int {
+	return scoreRank(highScores.Levels[level.Name], score)
+}
+
+// insertScore puts the entry in its place, returning the table cut down
+// to size and where it went or -1 if it didn't make it.
+func insertScore(table []HighScore, entry HighScore) ([]HighScore, int) {
+	rank := scoreRank(table, entry.Score)
+	if rank < 0 {
+		return table, -1
+	}
+	table = append(table, HighScore{})
+	copy(table[rank+1:], table[rank:])
+	table[rank] = entry
+	if len(table) > maxHighScores {
+		table = table[:maxHighScores]
+	}
+	return table, rank
+}
+
+// addHighScore records a flight in its level's and difficulty's tables
+// and saves them, returning the place in the level's table or -1.
+func addHighScore(level Level, entry HighScore) int {
+	var rank int
+	highScores.Levels[level.Name], rank = insertScore(highScores.Levels[level.Name], entry)
+	difficulty := difficultyName(level.Difficulty)
+	highScores.Difficulties[difficulty], _ = insertScore(highScores.Difficulties[difficulty], entry)
+	log.Printf("Score %.0f on %s placed %d\n", entry.Score, level.Name, rank+1)
+	saveHighScores()
+	return rank
+}
+
+// ScoreTable is one of the tables picked on the high score screen.
+type ScoreTable struct {
+	Title  string
+	Scores []HighScore
+}
+
+func scoreTables() []ScoreTable {
+	tables := []ScoreTable{}
+	for _, level := range levels {
+		tables = append(tables, ScoreTable{Title: level.Name, Scores: highScores.Levels[level.Name]})
+	}
+	for _, name := range difficultyNames {
+		tables = append(tables, ScoreTable{Title: "All " + name + " levels", Scores: highScores.Difficulties[name]})
+	}
+	return tables
+}
+
+func scoreLine(rank int, entry HighScore) string {
+	return fmt.Sprintf("%2d %-10.10s %7.0f %s %5.0f %5.1f", rank+1, entry.Name, entry.Score, entry.Date.Format("2006-01-02"), entry.Fuel, entry.Speed)
+}
+
+func displayHighScores(s tcell.Screen, table ScoreTable, highlight int) {
+	styleTitle := tcell.StyleDefault.Foreground(color.Yellow).Background(color.Black)
+	styleNormal := tcell.StyleDefault.Foreground(color.White).Background(color.Black)
+	styleSelected := tcell.StyleDefault.Foreground(color.Black).Background(color.Green)
+
+	width, height := s.Size()
+	header := " # Name         Score Date        Fuel Speed"
+	boxWidth := len(header) + 8
+	boxHeight := maxHighScores + 8
+	boxX := (width - boxWidth) / 2
+	boxY := (height - boxHeight) / 2
+	drawBox(s, boxX, boxY, boxWidth, boxHeight, styleNormal)
+
+	y := boxY + 2
+	drawTextCentre(s, width, y, styleTitle, "< "+table.Title+" >")
+	drawText(s, boxX+4, y+2, boxX+boxWidth, y+2, styleTitle, header)
+	for i := 0; i < maxHighScores; i++ {
+		style := styleNormal
+		line := fmt.Sprintf("%2d", i+1)
+		if i < len(table.Scores) {
+			line = scoreLine(i, table.Scores[i])
+		}
+		if i == highlight {
+			style = styleSelected
+		}
+		drawText(s, boxX+4, y+3+i, boxX+boxWidth, y+3+i, style, line)
+	}
+}
+
+// runHighScores shows the tables, left and right to change table,
+// starting on the named one with the entry at highlight picked out.
+func runHighScores(s tcell.Screen, title string, highlight int) {
+	tables := scoreTables()
+	selected := 0
+	for i, table := range tables {
+		if table.Title == title {
+			selected = i
+		}
+	}
+
+	s.Clear()
+	for {
+		if tables[selected].Title != title {
+			highlight = -1
+		}
+		displayHighScores(s, tables[selected], highlight)
+		s.Show()
+
+		ev := <-s.EventQ()
+		switch ev := ev.(type) {
+		case *tcell.EventResize:
+			s.Sync()
+			s.Clear()
+		case *tcell.EventKey:
+			switch ev.Key() {
+			case tcell.KeyLeft, tcell.KeyUp:
+				selected = (selected + len(tables) - 1) % len(tables)
+				playSound(SoundMenuMove)
+			case tcell.KeyRight, tcell.KeyDown:
+				selected = (selected + 1) % len(tables)
+				playSound(SoundMenuMove)
+			case tcell.KeyEnter, tcell.KeyEscape:
+				s.Clear()
+				return
+			}
+			s.Clear()
+		}
+	}
+}
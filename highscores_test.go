@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func scoresOf(table []HighScore) []float64 {
+	scores := []float64{}
+	for _, entry := range table {
+		scores = append(scores, entry.Score)
+	}
+	return scores
+}
+
+// fullTable has maxHighScores entries from 1000 down in steps of 100
+func fullTable() []HighScore {
+	table := []HighScore{}
+	for i := 0; i < maxHighScores; i++ {
+		table = append(table, HighScore{Name: fmt.Sprint("P", i), Score: float64(1000 - i*100)})
+	}
+	return table
+}
+
+func TestScoreRank(t *testing.T) {
+	tests := []struct {
+		name  string
+		table []HighScore
+		score float64
+		want  int
+	}{
+		{"empty table", nil, 10, 0},
+		{"best", fullTable(), 2000, 0},
+		{"middle", fullTable(), 750, 3},
+		// a tie goes under the score already there
+		{"tie", fullTable(), 800, 3},
+		{"tie with the last", fullTable(), 100, -1},
+		{"below a full table", fullTable(), 50, -1},
+		{"last place in a short table", fullTable()[:5], 0, 5},
+	}
+	for _, test := range tests {
+		if got := scoreRank(test.table, test.score); got != test.want {
+			t.Errorf("%s: rank %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestInsertScore(t *testing.T) {
+	table, rank := insertScore(fullTable(), HighScore{Name: "New", Score: 800})
+	if rank != 3 || table[3].Name != "New" || table[2].Name != "P2" {
+		t.Errorf("tie placed at %d in %v", rank, scoresOf(table))
+	}
+	if len(table) != maxHighScores || table[maxHighScores-1].Score != 200 {
+		t.Errorf("full table not cut down, %v", scoresOf(table))
+	}
+
+	table, rank = insertScore(fullTable(), HighScore{Name: "Low", Score: 50})
+	if rank != -1 || len(table) != maxHighScores || table[maxHighScores-1].Name != "P9" {
+		t.Errorf("score that didn't place changed the table, rank %d %v", rank, scoresOf(table))
+	}
+
+	table, rank = insertScore(nil, HighScore{Name: "First", Score: 0})
+	if rank != 0 || len(table) != 1 {
+		t.Errorf("first score placed at %d in %v", rank, scoresOf(table))
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+// Instruments are the readings shown on the HUD. Speeds are already
+// scaled for display, positive vertical speed is falling.
+type Instruments struct {
+	Altitude        float64
+	VerticalSpeed   float64
+	HorizontalSpeed float64
+	SafeSpeed       float64
+	Fuel            float64
+	FuelCapacity    float64
+	Hits            int
+	PermittedHits   int
+	Status          string
+}
+
+var hudPlacements = []string{"Top", "Bottom", "Side"}
+
+// lowFuel is the fraction of the tank that sets off the warning
+const lowFuel = 0.2
+
+const gaugeWidth = 6
+
+// radarPerRow is how much the radar altitude reads for every row, it is
+// in quadrant sub-pixels whatever the renderer
+const radarPerRow = 2
+
+// radarAltitude measures straight down from the ship's feet to the
+// terrain, in quadrant sub-pixels so it reads the same for every
+// renderer. Over a hole it reads to the bottom of the screen.
+func radarAltitude(terrain *Canvas, x, y float64) float64 {
+	d := 1
+	for int(y)+d < terrain.Height && terrain.get(int(x), int(y)+d) == 0 {
+		d++
+	}
+	return float64(d-1) * radarPerRow / float64(terrain.CellH)
+}
+
+// gauge draws a bar of width cells filled to fraction.
+func gauge(fraction float64, width int) string {
+	filled := int(math.Round(math.Max(0, math.Min(1, fraction)) * float64(width)))
+	if asciiMode() {
+		return strings.Repeat("=", filled) + strings.Repeat(".", width-filled)
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// speedColour is green while the descent is safe to land at, yellow
+// getting close and red beyond it.
+func speedColour(speed, safe float64) color.Color {
+	switch {
+	case speed < safe*0.75:
+		return color.Green
+	case speed < safe:
+		return color.Yellow
+	}
+	return color.Red
+}
+
+type hudItem struct {
+	text  string
+	style tcell.Style
+}
+
+func (in Instruments) items() []hudItem {
+	normal := tcell.StyleDefault.Foreground(color.Green).Background(color.Black)
+	warning := tcell.StyleDefault.Foreground(color.Red).Background(color.Black)
+
+	// the bar is full at twice the safe landing speed, climbing shows
+	// as an empty bar
+	vsi := in.VerticalSpeed / (in.SafeSpeed * 2)
+	vsiStyle := normal.Foreground(speedColour(in.VerticalSpeed, in.SafeSpeed))
+
+	fuel := in.Fuel / in.FuelCapacity
+	fuelStyle := normal
+	fuelLabel := "FUEL"
+	if fuel < lowFuel {
+		fuelStyle = warning
+		// flash the warning twice a second
+		if time.Now().UnixMilli()/250%2 == 0 {
+			fuelLabel = "LOW "
+		}
+	}
+
+	// a hit can still land in the frame the ship is lost
+	left := max(0, in.PermittedHits-in.Hits)
+	shield := strings.Repeat(string(glyph(0x25A0, '#')), left) + strings.Repeat(string(glyph(0x25A1, '-')), in.PermittedHits-left)
+	shieldStyle := normal
+	if left <= 1 {
+		shieldStyle = warning
+	}
+
+	items := []hudItem{
+		{fmt.Sprintf("ALT %5.1f", in.Altitude), normal},
+		{fmt.Sprintf("VS %6.1f %s", in.VerticalSpeed, gauge(vsi, gaugeWidth)), vsiStyle},
+		{fmt.Sprintf("HS %5.1f", in.HorizontalSpeed), normal},
+		{fmt.Sprintf("%s %s %3.0f", fuelLabel, gauge(fuel, gaugeWidth), in.Fuel), fuelStyle},
+		{"SHLD " + shield, shieldStyle},
+	}
+	if in.Status != "" {
+		items = append(items, hudItem{in.Status, normal.Foreground(color.Yellow)})
+	}
+	return items
+}
+
+// drawHUD draws the instrument panel in a row along the top or bottom
+// of the screen or stacked down the right hand side.
+func drawHUD(s tcell.Screen, in Instruments, placement string) {
+	width, height := s.Size()
+	items := in.items()
+	blank := tcell.StyleDefault.Background(color.Black)
+
+	if strings.EqualFold(placement, "Side") {
+		panelWidth := 0
+		for _, item := range items {
+			panelWidth = max(panelWidth, len([]rune(item.text))+2)
+		}
+		x := width - panelWidth
+		y := (height - len(items)) / 2
+		for i, item := range items {
+			drawText(s, x, y+i, width, y+i, blank, strings.Repeat(" ", panelWidth))
+			drawText(s, x+1, y+i, width, y+i, item.style, item.text)
+		}
+		return
+	}
+
+	y := 0
+	if strings.EqualFold(placement, "Bottom") {
+		y = height - 1
+	}
+	drawText(s, 0, y, width, y, blank, strings.Repeat(" ", width))
+	x := 0
+	for _, item := range items {
+		drawText(s, x, y, width, y, item.style, item.text)
+		x += len([]rune(item.text)) + 2
+	}
+}
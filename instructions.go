@@ -23,34 +23,7 @@ func displayInstructions(s tcell.Screen, x, y int, title string, items []string)
 	boxX := (width - boxWidth) / 2
 	boxY := (height - boxHeight) / 2
 
-	for row := 0; row < boxHeight; row++ {
-		for col := 0; col < boxWidth; col++ {
-			ch := ' '
-
-			if row == 0 {
-				ch = 0x2584
-			}
-			if row == boxHeight-1 {
-				ch = 0x2584
-			}
-			if col == 0 {
-				ch = 0x2590
-			}
-			if col == boxWidth-1 {
-				ch = 0x258C
-			}
-			if row == 0 && col == 0 {
-				ch = 0x2597
-			} else if row == 0 && col == boxWidth-1 {
-				ch = 0x2596
-			} else if row == boxHeight-1 && col == 0 {
-				ch = 0x2590
-			} else if row == boxHeight-1 && col == boxWidth-1 {
-				ch = 0x258c
-			}
-			s.SetContent(boxX+col, boxY+row, ch, nil, tcell.StyleDefault.Foreground(color.White))
-		}
-	}
+	drawBox(s, boxX, boxY, boxWidth, boxHeight, tcell.StyleDefault.Foreground(color.White))
 	drawTextCentre(s, width, y, styleTitle, title)
 	y += 2
 
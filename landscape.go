@@ -5,56 +5,79 @@ import (
 	"math"
 )
 
+type PadKind int
+
+const (
+	PadLanding PadKind = iota
+	PadFuel
+)
+
+// LandingCoOrds are in sub-pixels of the terrain canvas.
 type LandingCoOrds struct {
 	Start  int
 	End    int
 	Y      int
 	Points int
+	Kind   PadKind
 }
 
-func landscapeSin(width int, height int, buffer [][]byte, landingList []LandingCoOrds) []LandingCoOrds {
+func landscapeSin(terrain *Canvas, landingList []LandingCoOrds) []LandingCoOrds {
 	var currentLandingEntry = LandingCoOrds{Start: 0, End: -1, Y: -1, Points: 0}
+	// work in quadrant sized cells so every renderer gets the same hills
+	height := terrain.Height / terrain.CellH
+	yScale := float64(terrain.CellH) / 2
+	angleStep := 0.025 * 2 / float64(terrain.CellW)
+	surface := func(angle float64) float64 {
+		return (float64(height) + (math.Sin(angle) * float64(height/3)) + float64(height/2)) * yScale
+	}
 	angle := 0.0
 	var oldX = 0
-	var oldY = 0.0
-	for x := 0; x < width*2; x = x + 1 {
-		y := float64(height) + (math.Sin(angle) * float64(height/3)) + float64(height/2)
-		angle = angle + 0.025
-		drawLine(buffer, width, height, float64(oldX), float64(oldY), float64(x), float64(y), GREEN, true)
+	var oldY = surface(angle)
+	for x := 0; x < terrain.Width; x = x + 1 {
+		y := surface(angle)
+		angle = angle + angleStep
+		drawLine(terrain, float64(oldX), float64(oldY), float64(x), float64(y), terrainColour(y, float64(terrain.Height)))
 		oldX = x
 		oldY = y
 		if int(y) != currentLandingEntry.Y {
 			if currentLandingEntry.Points > 6 {
 				currentLandingEntry.End = x - 1
 				landingList = append(landingList, currentLandingEntry)
-				showLandingSite(currentLandingEntry, buffer, width, height)
 			}
 			currentLandingEntry = LandingCoOrds{Start: x, End: -1, Y: int(y), Points: 0}
 		} else {
 			currentLandingEntry.Points++
 		}
 	}
+	// every other pad is a fuel depot, the last pad always ends the flight
+	for i := range landingList {
+		if i%2 == 0 && i < len(landingList)-1 {
+			landingList[i].Kind = PadFuel
+		}
+		showLandingSite(landingList[i], terrain)
+	}
 	return landingList
 }
 
-// func landscapeSinHard(width int, height int, buffer [][]byte, landingList []LandingCoOrds) []LandingCoOrds {
+// func landscapeSinHard(terrain *Canvas, landingList []LandingCoOrds) []LandingCoOrds {
 // 	var currentLandingEntry = LandingCoOrds{Start: 0, End: -1, Y: -1, Points: 0}
+// 	height := terrain.Height / terrain.CellH
 // 	angle := 0.0
 // 	var oldX = 0
 // 	var oldY = 0.0
-// 	var addToAngle = float64(width) / 10000.0
+// 	var addToAngle = float64(terrain.Width/2) / 10000.0
 // 	log.Printf("add to angle %f\n", addToAngle)
-// 	for x := 0; x < width*2; x = x + 1 {
+// 	for x := 0; x < terrain.Width; x = x + 1 {
 // 		y := float64(height) + (math.Sin(angle) * float64(height/3)) + float64(height/2)
 // 		angle = angle + addToAngle
-// 		drawLine(buffer, width, height, float64(oldX), float64(oldY), float64(x), float64(y), GREEN, true)
+// 		drawLine(terrain, float64(oldX), float64(oldY), float64(x), float64(y), GREEN)
 // 		oldX = x
 // 		oldY = y
-// 		if int(y) != currentLandingEntry.Y && float64(x) > float64(width)*1.3 {
+// 		if int(y) != currentLandingEntry.Y && float64(x) > float64(terrain.Width/2)*1.3 {
 // 			if currentLandingEntry.Points > 6 {
 // 				currentLandingEntry.End = x - 1
 // 				landingList = append(landingList, currentLandingEntry)
-// 				showLandingSite(currentLandingEntry, buffer, width, height)
+// 				showLandingSite(currentLandingEntry, terrain)
 // 			}
 // 			currentLandingEntry = LandingCoOrds{Start: x, End: -1, Y: int(y), Points: 0}
 // 		} else {
@@ -65,65 +88,151 @@ func landscapeSin(width int, height int, buffer [][]byte, landingList []LandingC
 // 	oldY = float64(height) + (math.Sin(angle) * float64(height/3)) + float64(height/2) - 20
 // 	oldX = 20
 // 	angle = 0
-// 	for x := 20; x < width*2; x = x + 1 {
+// 	for x := 20; x < terrain.Width; x = x + 1 {
 // 		y := float64(height) + (math.Sin(angle) * float64(height/3)) + float64(height/2) - 30
 // 		angle = angle + addToAngle
-// 		drawLine(buffer, width, height, float64(oldX), float64(oldY), float64(x), float64(y), GREEN, true)
+// 		drawLine(terrain, float64(oldX), float64(oldY), float64(x), float64(y), GREEN)
 // 		oldX = x
 // 		oldY = y
 // 	}
 // 	return landingList
 // }
 
-func showLandingSite(currentLandingEntry LandingCoOrds, buffer [][]byte, width int, height int) {
+// showLandingSite paints the pad stripes a cell row at a time so each
+// stripe is a solid colour whatever the renderer.
+func showLandingSite(currentLandingEntry LandingCoOrds, terrain *Canvas) {
 	startYHere := currentLandingEntry.Y + 1
-	colourList := [...]byte{RED, GREEN, GREEN, RED}
-	colourListIndex := 0
-	if math.Mod(float64(currentLandingEntry.Y), 2) == 0 {
-		colourListIndex++
+	colourList := [...]Colour{RED, GREEN}
+	if currentLandingEntry.Kind == PadFuel {
+		colourList = [...]Colour{YELLOW, BLUE}
+	}
+	depth := 5 * terrain.CellH / 2
+	for y := startYHere; y < startYHere+depth; y++ {
+		// lower stripes fade into the ground
+		shade := 1 - float64(y-startYHere)/float64(depth)*0.4
+		colour := colourList[(y/terrain.CellH)%len(colourList)].scale(shade)
+		drawLine(terrain, float64(currentLandingEntry.Start), float64(y), float64(currentLandingEntry.End), float64(y), colour)
+	}
+}
+
+// fillTerrain makes the ground solid. Everything the ship can't reach
+// from the start position is filled, so caverns work as well as hills.
+// The flood stays off the screen edges, where lines are never drawn, and
+// the edge pixels then copy their neighbour. It returns the space the
+// ship can reach, true for each sub-pixel of it.
+func fillTerrain(terrain *Canvas, startX, startY int) [][]bool {
+	w, h := terrain.Width, terrain.Height
+	space := make([][]bool, h)
+	for y := range space {
+		space[y] = make([]bool, w)
+	}
+	if w < 3 || h < 3 {
+		return space
+	}
+	type XY struct{ X, Y int }
+	startX = max(1, min(startX, w-2))
+	startY = max(1, min(startY, h-2))
+	stack := []XY{{startX, startY}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if p.X < 1 || p.X > w-2 || p.Y < 1 || p.Y > h-2 || space[p.Y][p.X] || terrain.Pix[p.Y][p.X] != 0 {
+			continue
+		}
+		space[p.Y][p.X] = true
+		stack = append(stack, XY{p.X + 1, p.Y}, XY{p.X - 1, p.Y}, XY{p.X, p.Y + 1}, XY{p.X, p.Y - 1})
+	}
+	for y := 0; y < h; y++ {
+		space[y][0] = space[y][1]
+		space[y][w-1] = space[y][w-2]
 	}
-	for y := startYHere; y < startYHere+5; y++ {
-		drawLine(buffer, width, height, float64(currentLandingEntry.Start), float64(y), float64(currentLandingEntry.End), float64(y), colourList[colourListIndex], true)
-		colourListIndex++
-		if colourListIndex >= len(colourList) {
-			colourListIndex = 0
+	copy(space[0], space[1])
+	copy(space[h-1], space[h-2])
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if space[y][x] || terrain.Pix[y][x] != 0 {
+				continue
+			}
+			// a checker of two shades gives the rock some texture
+			shade := 0.5
+			if (x+y)%2 == 0 {
+				shade = 0.4
+			}
+			terrain.Pix[y][x] = terrainColour(float64(y), float64(h)).scale(shade)
 		}
 	}
+	return space
 }
 
-func landscapeHard(width int, height int, buffer [][]byte, landingList []LandingCoOrds) []LandingCoOrds {
+func landscapeHard(terrain *Canvas, landingList []LandingCoOrds) []LandingCoOrds {
 	type XY struct {
 		StartX float64
 		StartY float64
 		EndX   float64
 		EndY   float64
 	}
-	h := float64(height * 2)
-	w := float64(width * 2)
-	landingPadX := 50.0
+	h := float64(terrain.Height)
+	w := float64(terrain.Width)
+	// fixed x positions were laid out in quadrant sub-pixels
+	xs := float64(terrain.CellW) / 2
+	landingPadX := 50.0 * xs
 	landingPadY := h * 0.70
+	// the fuel depot sits on this ledge, wherever it comes out drawn
+	ledge := XY{w * 0.65, h * 0.55, w, h * 0.55}
 	coords := [...]XY{
 
-		{21, h * .25, 21, h},
-		// {21, h * 0.35, w * 0.5, h * 0.35},
-		{21, h * 0.35, w * 0.5, h * 0.55},
-		{w * 0.65, h * 0.55, w, h * 0.55},
+		{21 * xs, h * .25, 21 * xs, h},
+		// {21 * xs, h * 0.35, w * 0.5, h * 0.35},
+		{21 * xs, h * 0.35, w * 0.5, h * 0.55},
+		ledge,
 		{w * 0.65, h * 0.55, w * 0.65, h * 0.95},
 		{1, h * 0.95, w * 0.65, h * 0.95},
-		{w * 0.5, h * 0.80, 40, h * 0.80},
-		{1, h * 0.25, 21, h * 0.25},
+		{w * 0.5, h * 0.80, 40 * xs, h * 0.80},
+		{1, h * 0.25, 21 * xs, h * 0.25},
 		{w * 0.5, h * 0.55, w * 0.5, h * 0.80},
-		{landingPadX, landingPadY, landingPadX + 20, landingPadY},
+		{landingPadX, landingPadY, landingPadX + 20*xs, landingPadY},
 	}
-	var currentLandingEntry = LandingCoOrds{Start: int(landingPadX), End: int(landingPadX) + 20, Y: int(landingPadY), Points: 10}
-
-	landingList = append(landingList, currentLandingEntry)
-	showLandingSite(currentLandingEntry, buffer, width, height)
-
 	for _, v := range coords {
-		drawLine(buffer, width, height, v.StartX, v.StartY, v.EndX, v.EndY, GREEN, true)
+		drawLine(terrain, v.StartX, v.StartY, v.EndX, v.EndY, terrainColour((v.StartY+v.EndY)/2, h))
 		log.Printf("draw line %f,%f to %f,%f\n", v.StartX, v.StartY, v.EndX, v.EndY)
 	}
 
+	// clear of the wall the ledge starts on, in the middle of its flat
+	var fuelEntry = flattest(terrain, int(ledge.StartX)+1, int(ledge.EndX)-1)
+	if padWidth := int(16 * xs); fuelEntry.End-fuelEntry.Start >= padWidth {
+		fuelEntry.Start += (fuelEntry.End - fuelEntry.Start - padWidth) / 2
+		fuelEntry.End = fuelEntry.Start + padWidth
+	}
+	fuelEntry.Points = 8
+	fuelEntry.Kind = PadFuel
+	var currentLandingEntry = LandingCoOrds{Start: int(landingPadX), End: int(landingPadX + 20*xs), Y: int(landingPadY), Points: 10}
+
+	landingList = append(landingList, fuelEntry, currentLandingEntry)
+	showLandingSite(fuelEntry, terrain)
+	showLandingSite(currentLandingEntry, terrain)
+
 	return landingList
 }
+
+// flattest finds the longest level stretch of ground, as drawn, between
+// fromX and toX. The surface is the first terrain sub-pixel down each
+// column.
+func flattest(terrain *Canvas, fromX, toX int) LandingCoOrds {
+	best := LandingCoOrds{Start: fromX, End: fromX - 1}
+	run := LandingCoOrds{Y: -1}
+	for x := fromX; x <= toX; x++ {
+		y := 0
+		for y < terrain.Height-1 && terrain.get(x, y) == 0 {
+			y++
+		}
+		if y != run.Y {
+			run = LandingCoOrds{Start: x, Y: y}
+		}
+		run.End = x
+		if run.End-run.Start > best.End-best.Start {
+			best = run
+		}
+	}
+	return best
+}
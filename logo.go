@@ -1,95 +1,23 @@
 package main
 
 import (
-	"embed"
-	"image"
-	"image/draw"
-	"log"
-
 	"github.com/gdamore/tcell/v3"
-	"github.com/gdamore/tcell/v3/color"
-	"golang.org/x/image/font"
-	"golang.org/x/image/font/opentype"
-	"golang.org/x/image/math/fixed"
 )
 
-func getLogoPixels(logo string) []*image.RGBA {
-	logoText := make([]rune, 0)
-	returnPixels := make([]*image.RGBA, 0)
-	for _, char := range logo {
-		logoText = append(logoText, char)
-	}
-	log.Println("logo text", logoText)
-
-	for _, char := range logoText {
-		pixels := getPixelsForLetter(char)
-		returnPixels = append(returnPixels, pixels)
-	}
-
-	return returnPixels
-}
-
-func drawLogo(s tcell.Screen, startX int, pixels []*image.RGBA) bool {
-
-	_, startY := s.Size()
-	startY = startY / 10
+var logoColours = []Colour{RED, GREEN, BLUE, YELLOW, CYAN, rgb(0x8b, 0x00, 0x8b), WHITE}
 
-	letterColourList := []tcell.Color{color.Red, color.Green, color.Blue, color.Yellow, color.LightCyan, color.DarkMagenta, color.White}
-	letterColourIndex := 0
-	x := startX
-	for _, charPixels := range pixels {
-		for py := 0; py < charPixels.Bounds().Dy(); py++ {
-			for px := 0; px < charPixels.Bounds().Dx(); px++ {
-				r, g, b, a := charPixels.At(px, py).RGBA()
-				if a > 0 || r > 0 || g > 0 || b > 0 {
-					s.SetContent(x+px, startY+py, 'x', nil, tcell.StyleDefault.Foreground(letterColourList[letterColourIndex]).Background(color.Black))
-				}
-			}
-		}
-		letterColourIndex++
-		if letterColourIndex >= len(letterColourList) {
-			letterColourIndex = 0
-		}
-		x += charPixels.Bounds().Dx() + 2
-	}
-	if x < 0 {
-		return true
-	}
-	return false
+// newLogo rasterises the logo half the screen high for the renderer.
+func newLogo(s tcell.Screen, r *Renderer, text string) *Sprite {
+	_, height := s.Size()
+	return newBanner(text, height/2*r.CellH, logoColours)
 }
 
-//go:embed Roboto-Regular.ttf
-var fontFile embed.FS
-
-func getPixelsForLetter(character rune) *image.RGBA {
-	fontBytes, err := fontFile.ReadFile("Roboto-Regular.ttf")
-	if err != nil {
-		panic(err)
-	}
-	fnt, err := opentype.Parse(fontBytes)
-	if err != nil {
-		panic(err)
-	}
-
-	face, err := opentype.NewFace(fnt, &opentype.FaceOptions{
-		Size:    24,
-		DPI:     72,
-		Hinting: font.HintingFull,
-	})
-	if err != nil {
-		panic(err)
-	}
-	defer face.Close()
-
-	dr, mask, maskP, _, ok := face.Glyph(fixed.Point26_6{}, character)
-	if !ok {
-		panic("glyph not found")
-	}
-
-	var out *image.RGBA = image.NewRGBA(image.Rect(0, 0, dr.Dx(), dr.Dy()))
-
-	ink := image.NewUniform(color.Black)
-	draw.DrawMask(out, out.Bounds(), ink, image.Point{}, mask, maskP, draw.Over)
-
-	return out
+// drawLogo draws the logo startX cells across and reports whether it
+// has scrolled off the left of the screen.
+func drawLogo(s tcell.Screen, r *Renderer, startX int, logo *Sprite) bool {
+	width, height := s.Size()
+	c := newCanvas(width, height, r.CellW, r.CellH)
+	blit(c, logo, startX*r.CellW, height/10*r.CellH)
+	drawCanvasToScreen(c, s, r)
+	return startX*r.CellW+logo.W < 0
 }
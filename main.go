@@ -1,45 +1,47 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"math"
-	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v3"
 	"github.com/gdamore/tcell/v3/color"
 )
 
-const GREEN = 0
-const YELLOW = 1 << 4
-const RED = 2 << 4
-const BLUE = 4 << 4
+const GREEN = Colour(opaque | 0x3cb44b)
+const DARKGREEN = Colour(opaque | 0x0b3d12)
+const YELLOW = Colour(opaque | 0xffe119)
+const RED = Colour(opaque | 0xe6194b)
+const BLUE = Colour(opaque | 0x4363d8)
+const GREY = Colour(opaque | 0xa9a9a9)
+const WHITE = Colour(opaque | 0xf0f0f0)
+const CYAN = Colour(opaque | 0x7fd8ff)
 
 const instructions = `Welcome to Lunar Lander!
 
-Use the left and right arrow keys to control your lander's.
-Up arrow fires thruster. Press shift with left right also fires thrusters.
-Avoid the meteors!
-Your goal is to land safely on the moon's surface without crashing and as
-much fuel as possible.
-Watch the speed, positive is falling while negative is climbing.
+Left and right arrows steer, up or shift with an arrow fires thrusters.
+Land softly on a pad with as much fuel left as you can, avoid meteors.
+Speed is positive falling and negative climbing.
+Yellow pads refuel, thrust to lift off again. Red pads end the flight.
+Missions list objectives top right, land on their pads to pick up
+astronauts or crates, the flight ends once every objective is done.
+Some missions go on after landing, thrust to launch the ascent stage
+and climb to the dashed line or dock gently moving with the orbiter.
+Points come for fuel left, a soft landing near the pad centre and a
+quick flight, narrow pads multiply them and meteor hits cost you.
+Press P to save a screenshot.
 
 Press Enter or Escape to return to the main menu.`
 
 const title = "LunarLander"
 
-type ExplodeXY struct {
-	X, Y       float64
-	DirX, DirY float64
-	TTL        float64
-}
-type Explosion struct {
-	MeteorHit  bool
-	ExplodeNow int64
-	XY         []ExplodeXY
-}
+// landedPause is how long the landing stays on screen before the scores
+const landedPause = 2 * time.Second
 
 var debug = false
 
@@ -51,6 +53,33 @@ func Debug(format string, args ...any) {
 }
 
 func main() {
+	flag.StringVar(&settings.Renderer, "renderer", settings.Renderer, "display mode, quadrant, braille, sextant or \"half block\"")
+	flag.BoolVar(&settings.ASCII, "ascii", settings.ASCII, "draw with plain ASCII characters only")
+	flag.BoolVar(&settings.NoIntro, "no-intro", settings.NoIntro, "go straight to the menu")
+	flag.StringVar(&settings.LogoText, "logo", settings.LogoText, "text scrolled across the intro")
+	flag.StringVar(&settings.Attract, "attract", settings.Attract, "attract scenes in order, logo, demo, scores and credits, each with an optional time like demo:40s")
+	flag.StringVar(&settings.FontFile, "font", settings.FontFile, "TrueType or OpenType font file for the logo and banners")
+	flag.StringVar(&settings.ExportDir, "export-dir", settings.ExportDir, "directory screenshots are saved in")
+	flag.StringVar(&settings.ExportLevel, "export-level", settings.ExportLevel, "fly the named level without a terminal and save a screenshot")
+	flag.IntVar(&settings.ExportFrame, "export-frame", settings.ExportFrame, "frame of the flight -export-level saves")
+	flag.IntVar(&settings.ExportWidth, "export-width", settings.ExportWidth, "screen width in cells for -export-level")
+	flag.IntVar(&settings.ExportHeight, "export-height", settings.ExportHeight, "screen height in cells for -export-level")
+	flag.StringVar(&settings.Record, "record", settings.Record, "save a replay of each flight to this file")
+	flag.StringVar(&settings.Replay, "replay", settings.Replay, "fly a saved replay and exit")
+	flag.BoolVar(&settings.Headless, "headless", settings.Headless, "fly the -replay without a terminal")
+	flag.StringVar(&settings.GIF, "gif", settings.GIF, "record flights to this animated GIF")
+	flag.IntVar(&settings.GIFScale, "gif-scale", settings.GIFScale, "GIF size, each cell is 4 by 8 pixels times this")
+	flag.IntVar(&settings.GIFSkip, "gif-skip", settings.GIFSkip, "keep every this many frames in the GIF")
+	flag.StringVar(&settings.GIFPalette, "gif-palette", settings.GIFPalette, "GIF palette, adaptive, plan9 or websafe")
+	flag.StringVar(&settings.Cast, "cast", settings.Cast, "save flights as an asciicast to play with asciinema")
+	flag.BoolVar(&settings.Vario, "vario", settings.Vario, "beep the descent rate")
+	flag.BoolVar(&settings.Mute, "mute", settings.Mute, "start with the sound off")
+	flag.StringVar(&settings.HUD, "hud", settings.HUD, "instrument panel placement, top, bottom or side")
+	flag.Parse()
+	flag.Visit(func(f *flag.Flag) {
+		flagOverrides[f.Name] = f.Value.String()
+	})
+
 	if !IsWASM {
 		file, err := os.OpenFile(
 			"app.log",
@@ -72,8 +101,38 @@ func main() {
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
 	tcell.SetEncodingFallback(tcell.EncodingFallbackASCII)
+	loadHighScores()
+	loadProfiles()
+
+	if settings.Replay != "" {
+		var err error
+		playback, err = loadReplay(settings.Replay)
+		if err != nil {
+			log.Println("Replay not loaded", err)
+			fmt.Fprintln(os.Stderr, "Replay not loaded", err)
+			return
+		}
+		// the replay only flies the same if the sub-pixels are the same
+		settings.Renderer = playback.Renderer
+		settings.ExportWidth, settings.ExportHeight = playback.Width, playback.Height
+	}
+	if settings.ExportLevel != "" {
+		settings.Headless = true
+	}
+	if settings.Headless && playback == nil && settings.ExportLevel == "" {
+		fmt.Fprintln(os.Stderr, "-headless needs a -replay to fly")
+		return
+	}
 
-	s, err := tcell.NewScreen()
+	var s tcell.Screen
+	var err error
+	if settings.Headless {
+		s, err = newHeadlessScreen(settings.ExportWidth, settings.ExportHeight)
+	} else if settings.Cast != "" && !IsWASM {
+		s, err = newCastScreen(settings.Cast)
+	} else {
+		s, err = tcell.NewScreen()
+	}
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
@@ -81,6 +140,11 @@ func main() {
 	if err := s.Init(); err != nil {
 		log.Fatalf("%+v", err)
 	}
+	initSound(s)
+	if !unicodeSupported(s) {
+		log.Printf("Character set %s, using ASCII display\n", s.CharacterSet())
+		asciiTerminal = true
+	}
 
 	quit := func() {
 		maybePanic := recover()
@@ -91,34 +155,166 @@ func main() {
 	}
 	defer quit()
 
-	menu := []MenuItem{
+	if settings.ExportLevel != "" {
+		level, ok := findLevel(settings.ExportLevel)
+		if !ok {
+			log.Println("No level called", settings.ExportLevel)
+			fmt.Fprintln(os.Stderr, "No level called", settings.ExportLevel)
+			return
+		}
+		runGame(s, level, findShip(settings.Ship))
+		return
+	}
+	if playback != nil {
+		level, ok := findLevel(playback.Level)
+		if !ok {
+			log.Println("No level called", playback.Level)
+			return
+		}
+		if width, height := s.Size(); width != playback.Width || height != playback.Height {
+			log.Printf("Replay recorded at %dx%d playing at %dx%d, it won't fly the same\n", playback.Width, playback.Height, width, height)
+		}
+		runGame(s, level, findShip(playback.Ship))
+		return
+	}
 
+	menu := []MenuItem{}
+	// a different player brings their own settings with them, one can be
+	// picked after a high score as well as from the menu
+	var relabel func()
+	for _, level := range levels {
+		label := "Start Game " + level.Name
+		if len(level.Objectives) > 0 || level.Ascent != AscentNone {
+			label = "Mission " + level.Name
+		}
+		menu = append(menu, MenuItem{
+			Label: label,
+			Action: func() {
+				ship, ok := runHangar(s)
+				if ok {
+					runGame(s, level, ship)
+					relabel()
+				}
+			},
+		})
+	}
+	// the options are kept in a menu of their own so the main one fits on
+	// the screen
+	settingsMenu := []MenuItem{}
+	relabel = func() {
+		settingsMenu[0].Label = rendererLabel()
+		settingsMenu[1].Label = backgroundLabel()
+		settingsMenu[2].Label = hudLabel()
+		settingsMenu[3].Label = trajectoryLabel()
+		settingsMenu[4].Label = muteLabel()
+		settingsMenu[5].Label = varioLabel()
+		settingsMenu[6].Label = playerLabel()
+	}
+	settingsMenu = []MenuItem{
 		{
-			Label: "Start Game Easy",
+			Label:   rendererLabel(),
+			Setting: true,
 			Action: func() {
-				runGame(s, 0)
+				nextRenderer()
+				settingsMenu[0].Label = rendererLabel()
 			},
 		},
 		{
-			Label: "Start Game Hard",
+			Label:   backgroundLabel(),
+			Setting: true,
 			Action: func() {
-				runGame(s, 1)
+				settings.Background = !settings.Background
+				settingsMenu[1].Label = backgroundLabel()
+			},
+		},
+		{
+			Label:   hudLabel(),
+			Setting: true,
+			Action: func() {
+				nextHUD()
+				settingsMenu[2].Label = hudLabel()
+			},
+		},
+		{
+			Label:   trajectoryLabel(),
+			Setting: true,
+			Action: func() {
+				settings.Trajectory = !settings.Trajectory
+				settingsMenu[3].Label = trajectoryLabel()
+			},
+		},
+		{
+			Label:   muteLabel(),
+			Setting: true,
+			Action: func() {
+				toggleMute()
+				settingsMenu[4].Label = muteLabel()
+			},
+		},
+		{
+			Label:   varioLabel(),
+			Setting: true,
+			Action: func() {
+				settings.Vario = !settings.Vario
+				settingsMenu[5].Label = varioLabel()
+			},
+		},
+		{
+			Label:   playerLabel(),
+			Setting: true,
+			Action: func() {
+				runProfiles(s)
+				relabel()
+			},
+		},
+		{
+			Label: "Back",
+		},
+	}
+	// settings changed from the menu are kept with the player
+	for i := range settingsMenu {
+		if settingsMenu[i].Setting {
+			action := settingsMenu[i].Action
+			settingsMenu[i].Action = func() {
+				action()
+				saveProfiles()
+			}
+		}
+	}
+	menu = append(menu, []MenuItem{
+		{
+			Label:   "Settings",
+			Setting: true,
+			Action: func() {
+				s.Clear()
+				runMenu(s, "Settings", settingsMenu, nil)
+			},
+		},
+		{
+			Label: "High Scores",
+			Action: func() {
+				runHighScores(s, levels[0].Name, -1)
 			},
 		},
 		{
 			Label: "Instructions",
 			Action: func() {
-				runInstructions(s, title, instructions)
+				text := instructions
+				if IsWASM {
+					text = strings.Replace(text, "Press P to save a screenshot.\n", "", 1)
+				}
+				runInstructions(s, title, text)
 			},
 		},
 		{
 			Label: "Quit",
 			Action: func() {
+				saveProfiles()
 				quit()
 				os.Exit(0)
 			},
 		},
-	}
+	}...)
 	if IsWASM {
 		for i := range menu {
 			if menu[i].Label == "Quit" {
@@ -127,56 +323,34 @@ func main() {
 			}
 		}
 	}
-	s.Clear()
-	var end = false
-
-	width, _ := s.Size()
-	startX := 0
-
-	click := 0.0
-	var targetFps int64 = 30
-
-	startTime := time.Now()
-
-	pixels := getLogoPixels("BERNIESOFT")
-
-	for !end {
-
-		click++
+	renderer := chooseRenderer(s, settings.Renderer)
+	backdrop := func() {
 		s.Clear()
-		// avoid first draw as resize event causes flash
-		if click > 1 && drawLogo(s, startX, pixels) {
-			startX = width
-		}
-		s.Show()
-		time.Sleep(time.Millisecond * 100)
-		startX -= 1
-
-		select {
-		case ev := <-s.EventQ():
-			switch ev.(type) {
-			case *tcell.EventKey:
-				end = true
-			case *tcell.EventResize:
-				s.Sync()
-				width, _ = s.Size()
-				startX = width / 4
-			}
-		default:
-		}
-		checkAverageFps(startTime, click, targetFps)
+		drawLogo(s, renderer, 1, newLogo(s, renderer, settings.LogoText))
 	}
+	attractScenes := attractSequence(settings.Attract)
+	idle := func() {
+		runAttract(s, attractScenes)
+		backdrop()
+	}
+
 	s.Clear()
-	drawLogo(s, startX, pixels)
+	if !settings.NoIntro {
+		runAttract(s, attractScenes)
+	}
+	if currentProfile() == nil {
+		selectProfile(askPlayerName(s, "Welcome to Golunar"))
+		relabel()
+	}
+	backdrop()
 
-	end = false
+	end := false
 	for !end {
-		end = runMenu(s, title, menu)
+		end = runMenu(s, title, menu, idle)
 		// is WASM don't allow quit from menu
 		if IsWASM {
 			end = false
 		}
-		checkAverageFps(startTime, click, targetFps)
 	}
 
 }
@@ -194,19 +368,13 @@ func checkAverageFps(startTime time.Time, click float64, targetFps int64) {
 	}
 }
 
-func runGame(s tcell.Screen, level int) {
+func runGame(s tcell.Screen, level Level, ship *ShipDesign) {
 	defStyle := tcell.StyleDefault.Background(color.Reset).Foreground(color.Reset)
 
 	greenStyle := tcell.StyleDefault.Foreground(color.Green).Background(color.Black)
 
-	thrustStyle := tcell.StyleDefault.Foreground(color.Red).Background(color.Black)
-
-	styles := [...]tcell.Style{
-		tcell.StyleDefault.Foreground(color.Green).Background(color.Black),
-		tcell.StyleDefault.Foreground(color.Yellow).Background(color.Black),
-		tcell.StyleDefault.Foreground(color.Red).Background(color.Black),
-		tcell.StyleDefault.Foreground(color.Black).Background(color.Black),
-	}
+	renderer := chooseRenderer(s, settings.Renderer)
+	flights++
 
 	s.SetStyle(defStyle)
 	s.EnableMouse()
@@ -214,27 +382,39 @@ func runGame(s tcell.Screen, level int) {
 	s.Clear()
 
 	width, height := s.Size()
-	var buffer = make([][]byte, height)
+	var terrain *Canvas
+	var frame *Canvas
+	var background *Background
+	var titleBanner, landedBanner, gameOverBanner *Sprite
 	landingList := make([]LandingCoOrds, 0)
 
-	playerX, playerY := 10.0, 10.0
+	// start position in quadrant sub-pixels, scaled to the renderer
+	startX := 5 * renderer.CellW
+	startY := 5 * renderer.CellH
+	playerX, playerY := float64(startX), float64(startY)
 
 	log.Println("Begin game loop")
 
-	var targetGravity = 0.000017
+	// gravity is in sub-pixels so scale it to the renderer's cell height
+	var pixelScale = float64(renderer.CellH) / 2
+	var targetGravity = 0.000017 * pixelScale
 	var gravity = targetGravity
 	var gravityIncrease float64
 	var maxGravity float64
 	var speedChangeThrust float64
-	var fuel = 100.0
+	var maxFuel = ship.FuelCapacity
+	const refuelRate = 0.25
+	var fuel = maxFuel
 	var hits = 0
 	const permittedHits = 3
 
 	var speed = 0.0
+	var horizontalSpeed = 0.0
 	var maxSpeed float64
 
 	var maximumLandingSpeed float64 // 0.00100 // maxSpeed / 8
 	const displayMultiplier = 100000.0
+	var displayScale = displayMultiplier / pixelScale
 
 	var shouldReturn bool
 	var thrust = false
@@ -243,75 +423,146 @@ func runGame(s tcell.Screen, level int) {
 	var moveY = 0.0
 	var landed = false
 	var crashed = false
+	var refuelling = false
+	var parked = false
+	mission := newMission(level, ship.Mass)
+	var phase = PhaseDescent
+	// the landing is scored on touching down, an ascent adds to it
+	var score Score
+	var scored bool
+	// the landing or docking speed for the high score table
+	var flightSpeed float64
+	var click float64
+	var dockSpeed float64
+	var commandModule CommandModule
 	var setLandedOnce = false
+	var exportWanted = false
+
+	// banners are up to this many cells high, the level title shows
+	// for the first couple of seconds
+	const bannerRows = 8
+	const titleFrames = 120
 
 	// easier for debugging without gravity
 	var doGravity = true
 
 	setupTheMoon := func() {
 		width, height = s.Size()
-		buffer = make([][]byte, height)
+		terrain = newCanvas(width, height, renderer.CellW, renderer.CellH)
+		frame = newCanvas(width, height, renderer.CellW, renderer.CellH)
 		landingList = make([]LandingCoOrds, 0)
-		if level == 0 {
-			landingList = landscapeSin(width, height, buffer, landingList)
+		if level.Difficulty == 0 {
+			landingList = landscapeSin(terrain, landingList)
 		} else {
-			// landingList = landscapeSinHard(width, height, buffer, landingList)
-			landingList = landscapeHard(width, height, buffer, landingList)
+			// landingList = landscapeSinHard(terrain, landingList)
+			landingList = landscapeHard(terrain, landingList)
 		}
+		space := fillTerrain(terrain, startX, startY)
+		background = newBackground(terrain, level.Name, space)
+		titleBanner = fitBanner(frame, level.Name, bannerRows, []Colour{WHITE})
+		landedBanner = fitBanner(frame, "LANDED", bannerRows, []Colour{GREEN})
+		gameOverBanner = fitBanner(frame, "GAME OVER", bannerRows, []Colour{RED})
 		log.Printf("Landing points %v\n", landingList)
+		mission.place(len(landingList))
 		gravity = targetGravity
 		gravityIncrease = targetGravity / float64(height) * 0.005
 		maxGravity = targetGravity * float64(height) / 2 * 50
 		speedChangeThrust = maxGravity * 0.01
 		maxSpeed = maxGravity
 		maximumLandingSpeed = maxSpeed / 4
+		commandModule = newCommandModule(terrain)
 		if playerX < 0 {
 			playerX = 0
 		}
 		if playerY < 0 {
 			playerY = 0
 		}
-		if int(playerX) >= width*2 {
-			playerX = float64(width*2 - 1)
+		if int(playerX) >= terrain.Width {
+			playerX = float64(terrain.Width - 1)
 		}
-		if int(playerY) >= height*2 {
-			playerY = float64(height*2 - 1)
+		if int(playerY) >= terrain.Height {
+			playerY = float64(terrain.Height - 1)
 		}
 		s.Clear()
-		drawRunesToScreen(buffer, s, styles)
+		drawCanvasToScreen(terrain, s, renderer)
 	}
 
 	log.Printf("width is %d\n", width)
 	setupTheMoon()
 
-	var explosion = Explosion{
-		ExplodeNow: 0,
-		XY:         []ExplodeXY{},
-	}
-	var ExplosionDone int64 = -200
-	const ExplodeBy = 0.05
-	explosionDirection := [...]ExplodeXY{
-		{X: 0, Y: 0, DirX: ExplodeBy, DirY: ExplodeBy},
-		{X: 0, Y: 0, DirX: -ExplodeBy, DirY: ExplodeBy},
-		{X: 0, Y: 0, DirX: ExplodeBy, DirY: -ExplodeBy},
-		{X: 0, Y: 0, DirX: -ExplodeBy, DirY: -ExplodeBy},
-	}
-	explosionDirectionIndex := 0
+	// frames left to watch the explosion before returning to the menu
+	var explosionFrames = 0
+	const explosionLength = 240
+	// the ship keeps burning for a while after the crash
+	const burningLength = 40
 
 	setCrashed := func() {
 		if !setLandedOnce {
 			setLandedOnce = true
 			crashed = true
-			explosion.ExplodeNow = 40
-			explosion.MeteorHit = false
-			log.Println("")
+			explosionFrames = explosionLength
+			explosionEmitter.emit(playerX, playerY-1, 0, 30, pixelScale)
+			setEngine(false)
+			playSound(SoundCrash)
+			log.Println("Crashed")
 		}
 	}
+	landingPadAt := func(playerX, playerY float64) (int, bool) {
+		for i, v := range landingList {
+			if playerX > float64(v.Start) && playerX < float64(v.End) && math.Abs(playerY-float64(v.Y)) < 2 {
+				return i, true
+			}
+		}
+		return -1, false
+	}
+	scoreTouchdown := func(pad LandingCoOrds) Score {
+		return scoreLanding(Touchdown{Fuel: fuel, MaxFuel: maxFuel, Speed: speed * displayScale, SafeSpeed: maximumLandingSpeed * displayScale, Pad: pad, X: playerX, Frames: click, Hits: hits, Ship: ship})
+	}
 	setLanded := func() {
 		if !setLandedOnce {
+			index, ok := landingPadAt(playerX, playerY)
+			if !ok || parked || speed < 0 {
+				// sitting on or lifting off from a pad
+				return
+			}
+			pad := landingList[index]
 			if speed < maximumLandingSpeed {
+				if mission.touchDown(index) {
+					log.Printf("Mission objectives %d of %d\n", mission.completed(), mission.count())
+				}
+				finalPad := pad.Kind == PadLanding
+				if mission.active() {
+					finalPad = mission.complete()
+				}
+				if phase == PhaseAscent {
+					finalPad = false
+				}
+				if finalPad && level.Ascent != AscentNone {
+					log.Println("Landed, ascent stage ready")
+					playSound(SoundLanding)
+					score = scoreTouchdown(pad)
+					flightSpeed = speed * displayScale
+					phase = PhaseAscentReady
+					parked = true
+					speed = 0
+					gravity = targetGravity
+					return
+				}
+				if !finalPad {
+					log.Println("Touched down on pad", index)
+					playSound(SoundLanding)
+					parked = true
+					refuelling = pad.Kind == PadFuel
+					speed = 0
+					gravity = targetGravity
+					return
+				}
 				setLandedOnce = true
+				score = scoreTouchdown(pad)
+				scored = true
+				flightSpeed = speed * displayScale
 				log.Println("Landed well done")
+				playSound(SoundLanding)
 				landed = true
 			} else {
 				setCrashed()
@@ -319,48 +570,87 @@ func runGame(s tcell.Screen, level int) {
 		}
 	}
 	onLaunchPad := func(playerX, playerY float64) bool {
-		for _, v := range landingList {
-			if playerX > float64(v.Start) && playerX < float64(v.End) && math.Abs(playerY-float64(v.Y)) < 2 {
-				return true
-			}
-		}
-		return false
+		_, ok := landingPadAt(playerX, playerY)
+		return ok
 	}
 
 	clearMeteors()
+	clearParticles()
+	seed := time.Now().UnixNano()
+	if playback != nil {
+		seed = playback.Seed
+	}
+	seedMeteors(seed)
+	recording := &Replay{Level: level.Name, Ship: ship.Name, Renderer: renderer.Name, Width: width, Height: height, Seed: seed}
+	playbackNext := 0
+	var recorder *GIFRecorder
+	if settings.GIF != "" {
+		recorder = newGIFRecorder(flightPath(settings.GIF))
+	}
+	if caster != nil {
+		caster.begin(flightPath(settings.Cast), fmt.Sprintf("Golunar %s in the %s", level.Name, ship.Name), width, height)
+		s.Clear()
+		s.Sync()
+	}
+	// frames the engine sound carries on after the last burn
+	const engineHold = 40
+	lastBurn := -float64(engineHold)
+	fuelWarned := false
+	var vario Variometer
 	startTime := time.Now()
 	var targetFps int64 = 60
-	click := 0.0
+	click = 0
 	resized := false
 loop:
 
-	for explosion.ExplodeNow > ExplosionDone || !crashed && !landed {
+	for explosionFrames > 0 || !crashed && !landed {
 
 		click++
+		if playback != nil && playback.over(int(click)) {
+			log.Println("Replay ended with", playback.Ending, "at frame", playback.End)
+			// the frame past the end was never shown
+			click--
+			break loop
+		}
 		if resized {
 			setupTheMoon()
 			clearMeteors()
+			clearParticles()
 			resized = false
 		}
-		var oddEven = math.Mod(math.Round(float64(int(playerY)+1)), 2)
-
-		drawText(s, 0, 0, 100, 0, greenStyle, fmt.Sprintf("Play lunar lander speed=%.1f maximum landing speed %.0f fuel %0.f hits %d   ", speed*displayMultiplier, maximumLandingSpeed*displayMultiplier, fuel, hits))
 
 		updateMeteors()
+		impactMeteors(terrain, landingList)
+		updateParticles(terrain)
 
 		if !doGravity {
 			crashed = false
 			landed = false
 		}
 
+		// a replay rebuilds the moon on the frames the recording did
+		if playback != nil && playback.resizedAt(int(click)) {
+			resized = true
+			continue
+		}
+
 		select {
 		case ev := <-s.EventQ():
 			switch ev.(type) {
 			case *tcell.EventResize:
 				s.Sync()
-				resized = true
-				continue
+				if caster != nil {
+					caster.resize(s.Size())
+				}
+				if playback == nil {
+					recording.Resizes = append(recording.Resizes, int(click))
+					resized = true
+					continue
+				}
 			default:
+				if key, ok := ev.(*tcell.EventKey); ok && isExportKey(key) {
+					exportWanted = true
+				}
 				if doGravity {
 					thrust, moveX, shouldReturn = handleEventsForGame(ev, s)
 				} else {
@@ -376,13 +666,33 @@ loop:
 			moveX = 0.0
 			moveY = 0.0
 		}
+		if playback != nil {
+			thrust, moveX = playback.input(int(click), &playbackNext)
+		}
+		recording.record(int(click), thrust, moveX)
 		oldX, oldY := playerX, playerY
 		if thrust {
 			displayThrust = 200
 		}
 
+		if refuelling && fuel < maxFuel {
+			fuel = math.Min(fuel+refuelRate, maxFuel)
+		}
+		if parked && thrust && phase == PhaseAscentReady {
+			log.Println("Ascent stage lift off")
+			phase = PhaseAscent
+			fuel = ascentFuel
+			mission.Mass -= descentStageMass * ship.Mass
+		}
+		if parked && thrust && fuel > 0 {
+			log.Println("Lift off from pad")
+			parked = false
+			refuelling = false
+			speed = -speedChangeThrust * ship.Thrust / mission.Mass
+		}
+
 		if doGravity {
-			if !landed && !crashed {
+			if !landed && !crashed && !parked {
 				if speed > maxGravity {
 					speed = maxGravity
 				}
@@ -395,14 +705,15 @@ loop:
 
 				speed = speed + gravity
 
-				playerX = playerX + moveX
-				if playerX < 0 || int(playerX) >= width*2 || playerY < 0 || int(playerY) >= height*2 {
+				playerX = playerX + moveX*ship.RCSPower
+				if playerX < 0 || int(playerX) >= terrain.Width || playerY < 0 || int(playerY) >= terrain.Height {
 					playerX, playerY = oldX, oldY
 				}
 
 				if fuel > 0 && thrust {
 					fuel = fuel - 0.5
-					speed = speed - speedChangeThrust
+					lastBurn = click
+					speed = speed - speedChangeThrust*ship.Thrust/mission.Mass
 					gravity = 0 //gravity * 0.5
 					if speed < -maxSpeed {
 						speed = -maxSpeed
@@ -416,214 +727,306 @@ loop:
 			playerY = playerY + moveY
 		}
 
-		if playerY >= float64(height*2)-2 || playerY <= 2 {
+		// key repeat leaves gaps between burns so the engine is held on
+		// over them rather than stuttering
+		setEngine(!crashed && !landed && click-lastBurn < engineHold)
+		if fuel < lowFuel*maxFuel && !fuelWarned {
+			fuelWarned = true
+			playSound(SoundLowFuel)
+		} else if fuel >= lowFuel*maxFuel {
+			fuelWarned = false
+		}
+
+		if settings.Vario && doGravity && !landed && !crashed && !parked {
+			vario.update(speed, maximumLandingSpeed, radarAltitude(terrain, playerX, playerY))
+		}
+
+		// side thrust is applied in nudges so smooth it out for the HUD
+		horizontalSpeed = horizontalSpeed*0.95 + (playerX-oldX)*0.05
+
+		if playerY >= float64(terrain.Height)-2 || playerY <= 2 {
 			setCrashed()
 		}
 
-		// erase here so thrust up doesn't trip collision detection
-		if !crashed {
-			drawShip(buffer, width, height, float64(oldX), float64(oldY), false)
+		checkCollisionBelow(terrain, ship, playerX, playerY, onLaunchPad, setLanded, setCrashed)
+		checkCollisionBody(terrain, ship, playerX, playerY, setCrashed)
+		if phase == PhaseAscent && !landed && !crashed {
+			switch level.Ascent {
+			case AscentAltitude:
+				if playerY <= commandModule.Y {
+					log.Println("Reached target altitude")
+					playSound(SoundLanding)
+					setLandedOnce = true
+					landed = true
+				}
+			case AscentDock:
+				commandModule.update(terrain)
+				if commandModule.docking(playerX, playerY) {
+					dockSpeed = math.Abs(speed)
+					if commandModule.drifting(horizontalSpeed) {
+						log.Printf("Drifting at %.3f docking with command module at %.3f\n", horizontalSpeed, commandModule.Speed)
+						setCrashed()
+					} else if dockSpeed < maximumLandingSpeed {
+						log.Println("Docked with command module")
+						playSound(SoundLanding)
+						setLandedOnce = true
+						landed = true
+					} else {
+						setCrashed()
+					}
+				}
+			}
 		}
 
-		checkCollisionBelow(oddEven, playerY, buffer, playerX, onLaunchPad, setLanded, setCrashed)
-		checkCollisionAbove(oddEven, playerY, buffer, playerX, setCrashed)
-		if checkForMeteorCollision(playerX, playerY) {
-			explosion.ExplodeNow = 10
-			explosion.MeteorHit = true
+		// the wreck and a landed ship can't be hit any more
+		if !crashed && !landed && checkForMeteorCollision(terrain, ship, playerX, playerY) {
+			explosionEmitter.emit(playerX, playerY-1, 0, 10, pixelScale)
+			playSound(SoundMeteorHit)
 			hits++
 			if hits >= permittedHits {
 				setCrashed()
 			}
 		}
 
+		if settings.Background {
+			background.follow(playerX)
+			background.draw(frame)
+			frame.overlay(terrain)
+		} else {
+			frame.copyFrom(terrain)
+		}
 		if !crashed {
-			drawShip(buffer, width, height, float64(playerX), float64(playerY), true)
+			drawShip(frame, ship, playerX, playerY)
 		}
 
-		drawRunesToScreen(buffer, s, styles)
-
-		drawMeteors(s)
+		if displayThrust > 0 {
+			displayThrust--
+		}
+		if !crashed {
+			// the engine spools down over a few frames after letting go
+			throttle := float64(displayThrust) / 200
+			if !doGravity {
+				throttle = 1
+			}
+			emitExhaust(ship, playerX, playerY, throttle, pixelScale)
+			if thrust && fuel > 0 {
+				kickUpDust(terrain, playerX, playerY, throttle, pixelScale)
+			}
+		}
+		if explosionFrames > explosionLength-burningLength {
+			explosionEmitter.emit(playerX, playerY-1, 0, 1, pixelScale)
+		}
 
-		handleExplosion(&explosion, explosionDirectionIndex, explosionDirection, playerX, playerY, s, ExplosionDone)
-		explosion.ExplodeNow--
+		drawMeteors(frame)
 
-		if displayThrust > 0 || !doGravity {
+		// the trajectory assist is only offered on easy levels
+		showTrajectory := settings.Trajectory && level.Difficulty == 0 && doGravity && !landed && !crashed && !parked
+		var trajectory Trajectory
+		if showTrajectory {
+			trajectory = predictTrajectory(terrain, ship, playerX, playerY, speed, gravity, gravityIncrease, maxGravity)
+			drawTrajectory(frame, trajectory)
+		}
 
-			displayThrust--
+		drawParticles(frame)
+		bannerY := frame.Height / 4
+		if crashed {
+			drawBannerCentre(frame, gameOverBanner, bannerY)
+		} else if landed {
+			drawBannerCentre(frame, landedBanner, bannerY)
+		} else if click < titleFrames {
+			drawBannerCentre(frame, titleBanner, bannerY)
+		}
+		drawCanvasToScreen(frame, s, renderer)
+		drawParticleGlyphs(s, frame)
+		if asciiMode() && !crashed {
+			drawASCIIShip(s, frame, ship, playerX, playerY, float64(displayThrust)/200)
+		}
+		if showTrajectory {
+			safe := trajectory.ImpactSpeed < maximumLandingSpeed
+			for _, foot := range ship.feet() {
+				if !onLaunchPad(trajectory.Impact.X+foot.X, trajectory.Impact.Y) {
+					safe = false
+				}
+			}
+			drawImpactMarker(s, frame, trajectory, safe)
+		}
 
-			removePreviousThrust(oldY, oldX, s, thrustStyle)
-			if displayThrust > 1 || !doGravity {
-				drawThrust(playerX, playerY, displayThrust, s, thrustStyle)
+		status := ""
+		if phase == PhaseAscentReady {
+			status = "LAUNCH READY"
+		} else if refuelling {
+			status = "REFUELLING"
+		} else if parked {
+			status = "PARKED"
+		} else if phase == PhaseAscent {
+			status = "ASCENT"
+		}
+		drawHUD(s, Instruments{
+			Altitude:        radarAltitude(terrain, playerX, playerY),
+			VerticalSpeed:   speed * displayScale,
+			HorizontalSpeed: horizontalSpeed * displayScale,
+			SafeSpeed:       maximumLandingSpeed * displayScale,
+			Fuel:            fuel,
+			FuelCapacity:    maxFuel,
+			Hits:            hits,
+			PermittedHits:   permittedHits,
+			Status:          status,
+		}, settings.HUD)
+
+		if phase == PhaseAscent || phase == PhaseAscentReady {
+			if level.Ascent == AscentDock {
+				commandModule.draw(s, terrain)
+			} else {
+				drawTargetAltitude(s, terrain, int(commandModule.Y))
 			}
 		}
 
-		if landed {
+		if mission.active() {
+			drawMissionItems(s, terrain, mission, landingList)
+			drawObjectives(s, mission, greenStyle)
+		}
+
+		if explosionFrames > 0 {
+			explosionFrames--
+		}
+
+		if landed && phase == PhaseAscent {
+			if !scored {
+				score.addAscent(fuel, dockSpeed*displayScale, maximumLandingSpeed*displayScale)
+				score.setHits(hits)
+				scored = true
+				flightSpeed = dockSpeed * displayScale
+			}
+			drawText(s, 5, height/2, 200, height, greenStyle, fmt.Sprintf("Well done. Score %0.f docking speed %.1f fuel %0.f ", score.total(), dockSpeed*displayScale, fuel))
+		} else if landed {
 			if speed < maximumLandingSpeed {
-				score := (fuel + 1) / (speed + 1) / float64(hits+1)
-				drawText(s, 5, height/2, 200, height, greenStyle, fmt.Sprintf("Well done. Score %0.f speed was %.1f fuel %0.f hits %d ", score, speed*displayMultiplier, fuel, hits))
+				drawText(s, 5, height/2, 200, height, greenStyle, fmt.Sprintf("Well done. Score %0.f speed was %.1f fuel %0.f hits %d ", score.total(), speed*displayScale, fuel, hits))
 			} else {
 				setCrashed()
 			}
 		}
 		if crashed {
-			drawText(s, 5, height/2, 200, height, greenStyle, fmt.Sprintf("Crashed. Speed was %.1f target speed %.1f fuel %0.f hits %d", speed*displayMultiplier, maximumLandingSpeed*displayMultiplier, fuel, hits))
+			drawText(s, 5, height/2, 200, height, greenStyle, fmt.Sprintf("Crashed. Speed was %.1f target speed %.1f fuel %0.f hits %d", speed*displayScale, maximumLandingSpeed*displayScale, fuel, hits))
+		}
+		if mission.active() && (landed || crashed) {
+			result := "Mission failed"
+			if landed && mission.complete() {
+				result = "Mission complete"
+			}
+			drawText(s, 5, height/2+1, 200, height, greenStyle, fmt.Sprintf("%s, %d of %d objectives ", result, mission.completed(), mission.count()))
 		}
 
-		s.Show()
-		checkAverageFps(startTime, click, targetFps)
-	}
-}
-
-func handleExplosion(explosion *Explosion, explosionDirectionIndex int, explosionDirection [4]ExplodeXY, playerX float64, playerY float64, s tcell.Screen, ExplosionDone int64) {
-	if explosion.ExplodeNow > 0 {
-		log.Println("Explosion step", explosion.ExplodeNow)
-		explosionDirectionIndex = int(math.Mod(float64(explosionDirectionIndex+1), float64(len(explosionDirection))))
-
-		toAdd := ExplodeXY{
-			X:    playerX / 2,
-			Y:    playerY / 2,
-			DirX: explosionDirection[explosionDirectionIndex].DirX * (math.Mod(rand.Float64(), 1.0)),
-			DirY: explosionDirection[explosionDirectionIndex].DirY * (math.Mod(rand.Float64(), 1.0)),
-			TTL:  math.Mod(rand.Float64()*1000, 500) + 100,
+		if settings.ExportLevel != "" && int(click) >= settings.ExportFrame {
+			for _, name := range exportFrame(s, frame, renderer, level.Name) {
+				fmt.Println(name)
+			}
+			break loop
 		}
-		if explosion.MeteorHit {
-			toAdd.X = toAdd.X + toAdd.DirX/toAdd.DirX*2
-			toAdd.Y = toAdd.Y + toAdd.DirY/toAdd.DirY*2
+		if exportWanted {
+			exportFrame(s, frame, renderer, level.Name)
+			exportWanted = false
+		}
+		if recorder != nil {
+			recorder.capture(s, frame, renderer)
 		}
 
-		explosion.XY = append(explosion.XY, toAdd)
-		log.Println("Added explosion part", len(explosion.XY))
-	}
-
-	for i := range explosion.XY {
-		update := &explosion.XY[i]
-		s.SetContent(int(update.X), int(update.Y), ' ', nil, tcell.StyleDefault.Foreground(color.Red).Background(color.Black))
-		update.X = update.X + update.DirX
-		update.Y = update.Y + update.DirY
-		update.TTL--
-		if update.TTL <= 0 {
-			update.X = -1
-			update.Y = -1
+		if caster != nil {
+			caster.setFrame(int(click))
+		}
+		s.Show()
+		// without a terminal there is nobody watching so go flat out
+		if !settings.Headless {
+			checkAverageFps(startTime, click, targetFps)
 		}
-		s.SetContent(int(update.X), int(update.Y), '*', nil, tcell.StyleDefault.Foreground(color.Red).Background(color.Black))
 	}
-
-	if explosion.ExplodeNow <= ExplosionDone {
-		for i := range explosion.XY {
-			update := &explosion.XY[i]
-			s.SetContent(int(update.X), int(update.Y), ' ', nil, tcell.StyleDefault.Foreground(color.Red).Background(color.Black))
+	// a flight over before the frame asked for has its last frame saved
+	if settings.ExportLevel != "" && int(click) < settings.ExportFrame {
+		log.Printf("Flight over at frame %d before export frame %d\n", int(click), settings.ExportFrame)
+		fmt.Fprintf(os.Stderr, "Flight over at frame %d before frame %d, saving frame %d instead\n", int(click), settings.ExportFrame, int(click))
+		for _, name := range exportFrame(s, frame, renderer, level.Name) {
+			fmt.Println(name)
 		}
-		explosion.XY = []ExplodeXY{}
 	}
-}
-
-func drawThrust(playerX float64, playerY float64, displayThrust int, s tcell.Screen, thrustStyle tcell.Style) {
-	oddEvenX := math.Mod(math.Round(float64(int(playerX))), 2)
-	thrustY := int(playerY)/2 + 1
-	thrustX := int(playerX-1) / 2
-	const fullBlock1 = 0x2591
-	const fullBlock2 = 0x259a
-	const fullBlock3 = 0x259e
-	const halfLeft1 = 0x258C
-	const halfLeft2 = 0x2598
-	const halfLeft3 = 0x2596
-	const halfRight1 = 0x259d
-	const halfRight2 = 0x2590
-	const halfRight3 = 0x2597
-	thrustCharactersFullBlockHalfLeft := []rune{fullBlock1, halfLeft1, fullBlock2, halfLeft2, fullBlock3, halfLeft3}
-	thrustCharactersHalfRightFullBlock := []rune{halfRight1, fullBlock1, halfRight2, fullBlock2, halfRight3, fullBlock3}
-	thrustCharacter := int(math.Mod(float64(displayThrust), float64(len(thrustCharactersFullBlockHalfLeft)/2))) * 2
-	if thrustCharacter < 0 {
-		thrustCharacter = 0
-	}
-
-	if oddEvenX == 1 {
-		s.SetContent(thrustX, thrustY, thrustCharactersFullBlockHalfLeft[thrustCharacter], nil, thrustStyle)
-		s.SetContent(thrustX+1, thrustY, thrustCharactersFullBlockHalfLeft[thrustCharacter+1], nil, thrustStyle)
-	} else {
-		s.SetContent(thrustX, thrustY, thrustCharactersHalfRightFullBlock[thrustCharacter], nil, thrustStyle)
-		s.SetContent(thrustX+1, thrustY, thrustCharactersHalfRightFullBlock[thrustCharacter+1], nil, thrustStyle)
+	setEngine(false)
+	log.Printf("Flight over after %d frames at %.2f,%.2f speed %.5f fuel %.1f hits %d\n", int(click), playerX, playerY, speed, fuel, hits)
+	if recorder != nil {
+		recorder.save()
 	}
-}
-
-func removePreviousThrust(oldY float64, oldX float64, s tcell.Screen, thrustStyle tcell.Style) {
-	thrustY := int(oldY)/2 + 1
-	thrustX := int(oldX-1) / 2
-	s.SetContent(thrustX, thrustY, ' ', nil, thrustStyle)
-	s.SetContent(thrustX+1, thrustY, ' ', nil, thrustStyle)
-}
-
-func checkCollisionAbove(oddEven float64, playerY float64, buffer [][]byte, playerX float64, setCrashed func()) {
-	var yi int
-	if oddEven == 0 {
-		yi = int(int(playerY-2) / 2)
-	} else {
-		yi = int(int(playerY-3) / 2)
+	if caster != nil {
+		caster.end()
 	}
-
-	if yi < 0 || yi >= len(buffer) {
-		return
+	if settings.Record != "" {
+		// Escape leaves before the frame it was pressed on is shown
+		switch {
+		case shouldReturn:
+			recording.finish(int(click)-1, "escape")
+		case playback != nil && playback.over(int(click)+1):
+			recording.finish(playback.End, playback.Ending)
+		case crashed:
+			recording.finish(int(click), "crash")
+		case landed:
+			recording.finish(int(click), "landing")
+		default:
+			recording.finish(int(click), "export")
+		}
+		saveReplay(flightPath(settings.Record), recording)
 	}
-
-	xi := int(playerX / 2)
-	if xi < 0 || xi >= len(buffer[yi]) {
-		return
+	// only flights really flown go in the table
+	if landed && scored && playback == nil && !settings.Headless {
+		// give the landing a moment before the breakdown covers it
+		time.Sleep(landedPause)
+		runScoreBreakdown(s, level.Name+" landing", score)
+		if highScoreRank(level, score.total()) >= 0 {
+			// whoever typed their name gets the flight in their stats too
+			if name := askPlayerName(s, "New high score!"); !strings.EqualFold(name, playerName()) || currentProfile() == nil {
+				selectProfile(name)
+			}
+		}
+		rank := addHighScore(level, HighScore{Name: playerName(), Score: score.total(), Date: time.Now(), Fuel: fuel, Speed: flightSpeed})
+		runHighScores(s, level.Name, rank)
 	}
+	if playback == nil && !settings.Headless {
+		recordFlight(landed && scored, crashed, score.total(), int(click))
+	}
+}
 
-	var singleByte byte
-	if buffer[yi] != nil && buffer[yi][xi] != 0 {
-		if oddEven == 0 {
-			singleByte = buffer[yi][xi] & 3
-			if singleByte != 0 {
-				setCrashed()
-			}
-		} else {
-			singleByte = buffer[yi][xi] & 12
-			if singleByte != 0 {
+// checkCollisionBody crashes the ship if any part of it above the legs
+// is inside the ground.
+func checkCollisionBody(terrain *Canvas, ship *ShipDesign, playerX float64, playerY float64, setCrashed func()) {
+	mask := ship.Collision
+	x1, y1, _, _ := ship.bounds(int(playerX), int(playerY))
+	for y := 0; y < mask.H-1; y++ {
+		for x := 0; x < mask.W; x++ {
+			if mask.at(x, y) != 0 && terrain.get(x1+x, y1+y) != 0 {
 				setCrashed()
+				return
 			}
 		}
 	}
 }
 
-func checkCollisionBelow(oddEven float64, playerY float64, buffer [][]byte, playerX float64, onLaunchPad func(playerX float64, playerY float64) bool, setLanded func(), setCrashed func()) {
-	var yi int
-	if oddEven == 1 {
-		yi = int(int(playerY) / 2)
-	} else {
-		yi = int(int(playerY+1) / 2)
-	}
-
-	if yi < 0 || yi >= len(buffer) {
-		return
+// checkCollisionBelow looks at the row under the ship's legs, a safe
+// landing needs ground under every leg and all of them on a landing pad.
+func checkCollisionBelow(terrain *Canvas, ship *ShipDesign, playerX float64, playerY float64, onLaunchPad func(playerX float64, playerY float64) bool, setLanded func(), setCrashed func()) {
+	feet := ship.feet()
+	solid := 0
+	onPad := true
+	for _, foot := range feet {
+		x := playerX + foot.X
+		if terrain.get(int(x), int(playerY+foot.Y)+1) != 0 {
+			solid++
+		}
+		if !onLaunchPad(x, playerY) {
+			onPad = false
+		}
 	}
-
-	xi := int(playerX / 2)
-	if xi < 0 || xi >= len(buffer[yi]) {
+	if solid == 0 {
 		return
 	}
-
-	var singleByte byte
-	if buffer[yi] != nil && buffer[yi][xi] != 0 {
-		if oddEven == 1 {
-			singleByte = buffer[yi][xi] & 12
-			if singleByte != 0 {
-				if singleByte == 12 && onLaunchPad(playerX, playerY) {
-					setLanded()
-				} else {
-					setCrashed()
-				}
-			}
-		} else {
-			singleByte = buffer[yi][xi] & 3
-			if singleByte != 0 {
-				if singleByte == 3 && onLaunchPad(playerX, playerY) {
-					setLanded()
-				} else {
-					setCrashed()
-				}
-			}
-		}
+	if solid == len(feet) && onPad {
+		setLanded()
+	} else {
+		setCrashed()
 	}
 }
 
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"time"
+
 	"github.com/gdamore/tcell/v3"
 	"github.com/gdamore/tcell/v3/color"
 )
 
+// MenuItem with Setting set changes an option in place, the menu stays
+// open on it rather than returning.
 type MenuItem struct {
-	Label  string
-	Action func()
+	Label   string
+	Action  func()
+	Setting bool
 }
 
 func displayMenu(s tcell.Screen, x, y int, title string, items []MenuItem, selected int) {
@@ -30,34 +35,7 @@ func displayMenu(s tcell.Screen, x, y int, title string, items []MenuItem, selec
 	boxX := (width - boxWidth) / 2
 	boxY := (y - boxHeight/4)
 
-	for row := 0; row < boxHeight; row++ {
-		for col := 0; col < boxWidth; col++ {
-			ch := ' '
-
-			if row == 0 {
-				ch = 0x2584
-			}
-			if row == boxHeight-1 {
-				ch = 0x2584
-			}
-			if col == 0 {
-				ch = 0x2590
-			}
-			if col == boxWidth-1 {
-				ch = 0x258C
-			}
-			if row == 0 && col == 0 {
-				ch = 0x2597
-			} else if row == 0 && col == boxWidth-1 {
-				ch = 0x2596
-			} else if row == boxHeight-1 && col == 0 {
-				ch = 0x2590
-			} else if row == boxHeight-1 && col == boxWidth-1 {
-				ch = 0x258c
-			}
-			s.SetContent(boxX+col, boxY+row, ch, nil, tcell.StyleDefault.Foreground(color.White))
-		}
-	}
+	drawBox(s, boxX, boxY, boxWidth, boxHeight, tcell.StyleDefault.Foreground(color.White))
 	drawTextCentre(s, width, y, styleTitle, title)
 	y += 2
 
@@ -70,7 +48,9 @@ func displayMenu(s tcell.Screen, x, y int, title string, items []MenuItem, selec
 	}
 }
 
-func runMenu(s tcell.Screen, title string, items []MenuItem) bool {
+// runMenu calls idle, if given, when no key has been pressed for a while
+// and then carries on with the menu.
+func runMenu(s tcell.Screen, title string, items []MenuItem, idle func()) bool {
 	selected := 0
 
 	for {
@@ -82,7 +62,15 @@ func runMenu(s tcell.Screen, title string, items []MenuItem) bool {
 		displayMenu(s, x, y, title, items, selected)
 		s.Show()
 
-		ev := <-s.EventQ()
+		var ev tcell.Event
+		select {
+		case ev = <-s.EventQ():
+		case <-time.After(menuIdleTime):
+			if idle != nil {
+				idle()
+			}
+			continue
+		}
 		switch ev := ev.(type) {
 		case *tcell.EventResize:
 			s.Sync()
@@ -97,17 +85,23 @@ func runMenu(s tcell.Screen, title string, items []MenuItem) bool {
 				if selected < 0 {
 					selected = len(items) - 1
 				}
+				playSound(SoundMenuMove)
 
 			case tcell.KeyDown:
 				selected++
 				if selected >= len(items) {
 					selected = 0
 				}
+				playSound(SoundMenuMove)
 
 			case tcell.KeyEnter:
 				if items[selected].Action != nil {
 					items[selected].Action()
 				}
+				if items[selected].Setting {
+					s.Clear()
+					continue
+				}
 				return false
 			case tcell.KeyEscape:
 				return true
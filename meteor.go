@@ -1,12 +1,12 @@
 package main
 
 import (
+	"math"
 	"math/rand"
-
-	"github.com/gdamore/tcell/v3"
-	"github.com/gdamore/tcell/v3/color"
 )
 
+// Meteor positions and sizes are in terminal cells, they are drawn into
+// the frame canvas at whatever resolution the renderer gives.
 type Meteor struct {
 	OldX  float64
 	OldY  float64
@@ -19,6 +19,14 @@ type Meteor struct {
 
 var meteors []Meteor
 
+// meteors have their own random numbers so a replay seeded the same
+// gets the same meteors
+var meteorRandom = rand.New(rand.NewSource(1))
+
+func seedMeteors(seed int64) {
+	meteorRandom = rand.New(rand.NewSource(seed))
+}
+
 func updateMeteors() {
 
 	for i := 0; i < len(meteors); i++ {
@@ -47,43 +55,89 @@ func addMeteor(x, y, size, speed, ttl float64) {
 func clearMeteors() {
 	meteors = make([]Meteor, 0)
 }
-func drawMeteors(s tcell.Screen) {
-	_, height := s.Size()
-	if len(meteors) < 5 || rand.Float64() > 0.99 {
-		width, _ := s.Size()
-		meteorX := rand.Float64()*float64(width) + 3
-		meteorSize := rand.Float64()*2 + 1
-		meteorSpeed := rand.Float64()*0.01 + 0.02
-		meteorTtl := float64(height) * rand.Float64() * 100
+
+// meteorBounds gives the sub-pixel rectangle a meteor covers.
+func meteorBounds(c *Canvas, meteor Meteor) (int, int, int, int) {
+	x1 := int(meteor.X * float64(c.CellW))
+	y1 := int(meteor.Y * float64(c.CellH))
+	x2 := x1 + int(meteor.Size)*c.CellW
+	y2 := y1 + int(meteor.Size)*c.CellH
+	return x1, y1, x2, y2
+}
+
+func drawMeteors(c *Canvas) {
+	width := c.Width / c.CellW
+	height := c.Height / c.CellH
+	if len(meteors) < 5 || meteorRandom.Float64() > 0.99 {
+		meteorX := meteorRandom.Float64()*float64(width) + 3
+		meteorSize := meteorRandom.Float64()*2 + 1
+		meteorSpeed := meteorRandom.Float64()*0.01 + 0.02
+		meteorTtl := float64(height) * meteorRandom.Float64() * 100
 		// meteorX = 20
 
 		addMeteor(meteorX, 0, meteorSize, meteorSpeed, meteorTtl)
 
 	}
 	for _, meteor := range meteors {
-		for py := 0; py < int(meteor.Size); py++ {
-			for px := 0; px < int(meteor.Size); px++ {
-				s.SetContent(int(meteor.OldX)+px, int(meteor.OldY)+py, ' ', nil, tcell.StyleDefault.Foreground(color.Black).Background(color.Black))
-				if meteor.Ttl > 0.0 && int(meteor.Y)+py < height {
-					s.SetContent(int(meteor.X)+px, int(meteor.Y)+py, '*', nil, tcell.StyleDefault.Foreground(color.LightGray).Background(color.Black))
+		if meteor.Ttl <= 0.0 {
+			continue
+		}
+		x1, y1, x2, y2 := meteorBounds(c, meteor)
+		for py := y1; py < y2; py++ {
+			for px := x1; px < x2; px++ {
+				// knock the corners off bigger rocks and shade the edges
+				edgeX := px == x1 || px == x2-1
+				edgeY := py == y1 || py == y2-1
+				if edgeX && edgeY && x2-x1 > 2 {
+					continue
+				}
+				if edgeX || edgeY {
+					c.set(px, py, GREY.scale(0.7))
+				} else {
+					c.set(px, py, GREY)
 				}
-
 			}
 		}
 	}
 }
 
-func checkForMeteorCollision(shipX, shipY float64) bool {
-	shipX = shipX / 2
-	shipY = shipY / 2
+// impactMeteors digs a crater where a meteor hits the ground. Pads are
+// reinforced, a meteor landing on one just breaks up.
+func impactMeteors(terrain *Canvas, landingList []LandingCoOrds) {
 	for i := range meteors {
 		meteor := &meteors[i]
-		if shipX+0.75 >= meteor.X && shipX <= meteor.X+meteor.Size {
-			if shipY+1 >= meteor.Y && shipY-1 <= meteor.Y+meteor.Size {
-				meteor.Ttl = 0.0
-				return true
+		if meteor.Ttl <= 0.0 {
+			continue
+		}
+		x1, y1, x2, y2 := meteorBounds(terrain, *meteor)
+		x := (x1 + x2) / 2
+		if terrain.get(x, y2) == 0 {
+			continue
+		}
+		meteor.Ttl = 0.0
+		rx := float64(x2 - x1)
+		onPad := false
+		for _, pad := range landingList {
+			if float64(x) > float64(pad.Start)-rx && float64(x) < float64(pad.End)+rx && absInt(y2-pad.Y) <= y2-y1 {
+				onPad = true
 			}
 		}
+		if !onPad {
+			fillEllipse(terrain, float64(x), float64(y2), rx, float64(y2-y1)/2, 0)
+		}
+		impactEmitter.emit(float64(x), float64(y2)-1, -math.Pi/2, 6*int(meteor.Size), float64(terrain.CellH)/2)
+	}
+}
+
+func checkForMeteorCollision(c *Canvas, ship *ShipDesign, shipX, shipY float64) bool {
+	sx1, sy1, sx2, sy2 := ship.bounds(int(shipX), int(shipY))
+	for i := range meteors {
+		meteor := &meteors[i]
+		x1, y1, x2, y2 := meteorBounds(c, *meteor)
+		if sx2 > x1 && sx1 < x2 && sy2 > y1 && sy1 < y2 {
+			meteor.Ttl = 0.0
+			return true
+		}
 	}
 	return false
 }
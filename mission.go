@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+type ObjectiveKind int
+
+const (
+	ObjectiveRescue ObjectiveKind = iota
+	ObjectiveCargo
+	ObjectiveReturn
+)
+
+// Objective pad numbers index the landing list of the level, negative
+// numbers count back from the last pad so levels don't depend on how
+// many pads a landscape happens to generate for the screen size. At and
+// To are the pads they come to once placed on the landscape.
+type Objective struct {
+	Kind    ObjectiveKind
+	Pad     int
+	ToPad   int
+	Mass    float64
+	Loaded  bool
+	Done    bool
+	At      int
+	To      int
+	Skipped bool
+}
+
+type Level struct {
+	Name       string
+	Difficulty int
+	Objectives []Objective
+	Ascent     AscentKind
+}
+
+const astronautMass = 0.1
+const crateMass = 0.5
+
+var levels = []Level{
+	{Name: "Easy", Difficulty: 0},
+	{Name: "Hard", Difficulty: 1},
+	{
+		Name:       "Rescue",
+		Difficulty: 0,
+		Objectives: []Objective{
+			{Kind: ObjectiveRescue, Pad: 1, Mass: astronautMass},
+			{Kind: ObjectiveRescue, Pad: -2, Mass: astronautMass},
+			{Kind: ObjectiveReturn, Pad: 0},
+		},
+	},
+	{
+		Name:       "Cargo Run",
+		Difficulty: 0,
+		Objectives: []Objective{
+			{Kind: ObjectiveCargo, Pad: 0, ToPad: -1, Mass: crateMass},
+			{Kind: ObjectiveCargo, Pad: 1, ToPad: -2, Mass: crateMass},
+		},
+	},
+	{
+		Name:       "Cavern Rescue",
+		Difficulty: 1,
+		Objectives: []Objective{
+			{Kind: ObjectiveRescue, Pad: 1, Mass: astronautMass},
+			{Kind: ObjectiveCargo, Pad: 0, ToPad: 1, Mass: crateMass},
+		},
+	},
+	{Name: "Lift Off", Difficulty: 0, Ascent: AscentAltitude},
+	{Name: "Rendezvous", Difficulty: 1, Ascent: AscentDock},
+}
+
+func findLevel(name string) (Level, bool) {
+	for _, level := range levels {
+		if strings.EqualFold(level.Name, name) {
+			return level, true
+		}
+	}
+	return Level{}, false
+}
+
+type Mission struct {
+	Objectives []Objective
+	Mass       float64
+}
+
+func newMission(level Level, shipMass float64) *Mission {
+	mission := &Mission{Mass: shipMass}
+	// copy so the level data is untouched between flights
+	mission.Objectives = append([]Objective{}, level.Objectives...)
+	return mission
+}
+
+func resolvePad(pad, pads int) int {
+	if pads == 0 {
+		return -1
+	}
+	pad = pad % pads
+	if pad < 0 {
+		pad += pads
+	}
+	return pad
+}
+
+// nearestPad is the closest pad to want that ok allows, -1 if none.
+func nearestPad(want, pads int, ok func(int) bool) int {
+	want = max(want, 0)
+	for d := 0; d < pads; d++ {
+		for _, pad := range []int{want - d, want + d} {
+			if pad >= 0 && pad < pads && ok(pad) {
+				return pad
+			}
+		}
+	}
+	return -1
+}
+
+// place puts the objectives on the pads there are. Rescues, pickups and
+// the base each get a pad of their own and a crate is never delivered
+// where it was collected. The pad asked for is used when it is free,
+// otherwise the nearest free one, and an objective with no pad left is
+// skipped.
+func (m *Mission) place(pads int) {
+	taken := make([]bool, pads)
+	clashes := []int{}
+	for i := range m.Objectives {
+		objective := &m.Objectives[i]
+		objective.At, objective.To, objective.Skipped = resolvePad(objective.Pad, pads), -1, false
+		if objective.At >= 0 && !taken[objective.At] {
+			taken[objective.At] = true
+		} else {
+			clashes = append(clashes, i)
+		}
+	}
+	for _, i := range clashes {
+		objective := &m.Objectives[i]
+		objective.At = nearestPad(objective.At, pads, func(pad int) bool { return !taken[pad] })
+		if objective.At < 0 {
+			objective.Skipped = true
+			log.Printf("Objective %d skipped, only %d pads\n", i+1, pads)
+			continue
+		}
+		taken[objective.At] = true
+	}
+	for i := range m.Objectives {
+		objective := &m.Objectives[i]
+		if objective.Kind != ObjectiveCargo || objective.Skipped {
+			continue
+		}
+		// somewhere nothing else is waiting if it has to move
+		want := resolvePad(objective.ToPad, pads)
+		if want == objective.At {
+			want = nearestPad(want, pads, func(pad int) bool { return pad != objective.At && !taken[pad] })
+		}
+		objective.To = nearestPad(want, pads, func(pad int) bool { return pad != objective.At })
+		if objective.To < 0 {
+			objective.Skipped = true
+			log.Printf("Objective %d skipped, nowhere to deliver with %d pads\n", i+1, pads)
+		}
+	}
+}
+
+// active is false when none of the objectives fit on the landscape,
+// it is flown as a plain landing then.
+func (m *Mission) active() bool {
+	return m.count() > 0
+}
+
+func (m *Mission) complete() bool {
+	for _, objective := range m.Objectives {
+		if !objective.Done && !objective.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Mission) completed() int {
+	count := 0
+	for _, objective := range m.Objectives {
+		if objective.Done && !objective.Skipped {
+			count++
+		}
+	}
+	return count
+}
+
+// count is how many objectives there are on this landscape.
+func (m *Mission) count() int {
+	count := 0
+	for _, objective := range m.Objectives {
+		if !objective.Skipped {
+			count++
+		}
+	}
+	return count
+}
+
+// touchDown updates the objectives after a safe landing on pad and
+// reports whether anything changed.
+func (m *Mission) touchDown(pad int) bool {
+	changed := false
+	for i := range m.Objectives {
+		objective := &m.Objectives[i]
+		if objective.Done || objective.Skipped {
+			continue
+		}
+		switch objective.Kind {
+		case ObjectiveRescue:
+			if objective.At == pad {
+				objective.Done = true
+				m.Mass += objective.Mass
+				changed = true
+			}
+		case ObjectiveCargo:
+			if !objective.Loaded && objective.At == pad {
+				objective.Loaded = true
+				m.Mass += objective.Mass
+				changed = true
+			} else if objective.Loaded && objective.To == pad {
+				objective.Loaded = false
+				objective.Done = true
+				m.Mass -= objective.Mass
+				changed = true
+			}
+		}
+	}
+	// returning to base only counts once everything else is done
+	for i := range m.Objectives {
+		objective := &m.Objectives[i]
+		if objective.Kind == ObjectiveReturn && !objective.Done && !objective.Skipped && objective.At == pad {
+			done := true
+			for _, other := range m.Objectives {
+				if other.Kind != ObjectiveReturn && !other.Done && !other.Skipped {
+					done = false
+				}
+			}
+			if done {
+				objective.Done = true
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func (o Objective) describe() string {
+	switch o.Kind {
+	case ObjectiveRescue:
+		return fmt.Sprintf("Rescue astronaut at pad %d", o.At+1)
+	case ObjectiveCargo:
+		if o.Loaded {
+			return fmt.Sprintf("Deliver crate to pad %d", o.To+1)
+		}
+		return fmt.Sprintf("Collect crate at pad %d for pad %d", o.At+1, o.To+1)
+	case ObjectiveReturn:
+		return fmt.Sprintf("Return to base pad %d", o.At+1)
+	}
+	return ""
+}
+
+func drawObjectives(s tcell.Screen, mission *Mission, style tcell.Style) {
+	width, _ := s.Size()
+	i := 0
+	for _, objective := range mission.Objectives {
+		if objective.Skipped {
+			continue
+		}
+		box := "[ ]"
+		if objective.Done {
+			box = "[x]"
+		}
+		text := fmt.Sprintf("%s %s", box, objective.describe())
+		drawText(s, width-len(text)-1, i+1, width, i+1, style, text)
+		i++
+	}
+}
+
+// drawMissionItems shows the astronauts and crates still waiting on pads.
+func drawMissionItems(s tcell.Screen, c *Canvas, mission *Mission, landingList []LandingCoOrds) {
+	astronautStyle := tcell.StyleDefault.Foreground(color.White).Background(color.Black)
+	crateStyle := tcell.StyleDefault.Foreground(color.Orange).Background(color.Black)
+	for _, objective := range mission.Objectives {
+		if objective.Skipped || objective.At < 0 || objective.At >= len(landingList) {
+			continue
+		}
+		site := landingList[objective.At]
+		y := site.Y/c.CellH - 1
+		// once collected the item is blanked out rather than left behind
+		waiting := !objective.Done && !objective.Loaded
+		switch objective.Kind {
+		case ObjectiveRescue:
+			if waiting {
+				s.SetContent(site.End/c.CellW-1, y, glyph(0x263A, 'A'), nil, astronautStyle)
+			} else {
+				s.SetContent(site.End/c.CellW-1, y, ' ', nil, astronautStyle)
+			}
+		case ObjectiveCargo:
+			if waiting {
+				s.SetContent(site.Start/c.CellW+1, y, glyph(0x25A3, '#'), nil, crateStyle)
+			} else {
+				s.SetContent(site.Start/c.CellW+1, y, ' ', nil, crateStyle)
+			}
+		}
+	}
+}
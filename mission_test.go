@@ -0,0 +1,162 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// placed is where an objective should end up, To only matters for cargo
+type placed struct {
+	At, To  int
+	Skipped bool
+}
+
+func TestMissionPlace(t *testing.T) {
+	skipped := placed{Skipped: true}
+	tests := []struct {
+		level string
+		pads  int
+		want  []placed
+	}{
+		{"Rescue", 0, []placed{skipped, skipped, skipped}},
+		{"Rescue", 1, []placed{{At: 0}, skipped, skipped}},
+		{"Rescue", 2, []placed{{At: 1}, {At: 0}, skipped}},
+		{"Rescue", 3, []placed{{At: 1}, {At: 2}, {At: 0}}},
+		{"Rescue", 5, []placed{{At: 1}, {At: 3}, {At: 0}}},
+		{"Cargo Run", 0, []placed{skipped, skipped}},
+		{"Cargo Run", 1, []placed{skipped, skipped}},
+		{"Cargo Run", 2, []placed{{At: 0, To: 1}, {At: 1, To: 0}}},
+		{"Cargo Run", 3, []placed{{At: 0, To: 2}, {At: 1, To: 2}}},
+		{"Cargo Run", 5, []placed{{At: 0, To: 4}, {At: 1, To: 3}}},
+		{"Cavern Rescue", 0, []placed{skipped, skipped}},
+		{"Cavern Rescue", 1, []placed{{At: 0}, skipped}},
+		{"Cavern Rescue", 2, []placed{{At: 1}, {At: 0, To: 1}}},
+		{"Cavern Rescue", 3, []placed{{At: 1}, {At: 0, To: 1}}},
+		{"Cavern Rescue", 5, []placed{{At: 1}, {At: 0, To: 1}}},
+	}
+	for _, test := range tests {
+		level, ok := findLevel(test.level)
+		if !ok {
+			t.Fatalf("no level %s", test.level)
+		}
+		mission := newMission(level, 1)
+		mission.place(test.pads)
+		count := 0
+		for i, objective := range mission.Objectives {
+			want := test.want[i]
+			if objective.Skipped != want.Skipped {
+				t.Errorf("%s with %d pads: objective %d skipped %v, want %v", test.level, test.pads, i+1, objective.Skipped, want.Skipped)
+				continue
+			}
+			if objective.Skipped {
+				continue
+			}
+			count++
+			if objective.At != want.At {
+				t.Errorf("%s with %d pads: objective %d at pad %d, want %d", test.level, test.pads, i+1, objective.At, want.At)
+			}
+			if objective.Kind == ObjectiveCargo && objective.To != want.To {
+				t.Errorf("%s with %d pads: objective %d to pad %d, want %d", test.level, test.pads, i+1, objective.To, want.To)
+			}
+		}
+		if mission.count() != count {
+			t.Errorf("%s with %d pads: count %d, want %d", test.level, test.pads, mission.count(), count)
+		}
+		if mission.active() != (count > 0) {
+			t.Errorf("%s with %d pads: active %v with %d objectives", test.level, test.pads, mission.active(), count)
+		}
+	}
+}
+
+func TestMissionPlaceDistinct(t *testing.T) {
+	for _, level := range levels {
+		for pads := 0; pads <= 6; pads++ {
+			mission := newMission(level, 1)
+			mission.place(pads)
+			used := map[int]bool{}
+			for i, objective := range mission.Objectives {
+				if objective.Skipped {
+					continue
+				}
+				if used[objective.At] {
+					t.Errorf("%s with %d pads: objective %d shares pad %d", level.Name, pads, i+1, objective.At)
+				}
+				used[objective.At] = true
+				if objective.Kind == ObjectiveCargo && objective.To == objective.At {
+					t.Errorf("%s with %d pads: crate %d delivered where it was collected", level.Name, pads, i+1)
+				}
+			}
+		}
+	}
+}
+
+func TestMissionTouchDown(t *testing.T) {
+	level := Level{
+		Name: "Test",
+		Objectives: []Objective{
+			{Kind: ObjectiveRescue, Pad: 1, Mass: astronautMass},
+			{Kind: ObjectiveCargo, Pad: 2, ToPad: 3, Mass: crateMass},
+			{Kind: ObjectiveReturn, Pad: 0},
+		},
+	}
+	const pads = 4
+	mission := newMission(level, 1)
+	mission.place(pads)
+
+	steps := []struct {
+		pad       int
+		changed   bool
+		mass      float64
+		completed int
+	}{
+		// the base doesn't count until everything else is done
+		{0, false, 1, 0},
+		{1, true, 1 + astronautMass, 1},
+		{1, false, 1 + astronautMass, 1},
+		{2, true, 1 + astronautMass + crateMass, 1},
+		{3, true, 1 + astronautMass, 2},
+		{0, true, 1 + astronautMass, 3},
+	}
+	for i, step := range steps {
+		// a resize places the objectives again part way through
+		if i == 2 || i == 4 {
+			mission.place(pads)
+		}
+		if changed := mission.touchDown(step.pad); changed != step.changed {
+			t.Errorf("step %d on pad %d changed %v, want %v", i, step.pad, changed, step.changed)
+		}
+		if math.Abs(mission.Mass-step.mass) > 1e-9 {
+			t.Errorf("step %d mass %.2f, want %.2f", i, mission.Mass, step.mass)
+		}
+		if mission.completed() != step.completed {
+			t.Errorf("step %d completed %d, want %d", i, mission.completed(), step.completed)
+		}
+	}
+	if !mission.complete() {
+		t.Error("mission not complete after returning to base")
+	}
+	// level data is copied so the next flight starts afresh
+	if level.Objectives[0].Done || newMission(level, 1).completed() != 0 {
+		t.Error("touching down changed the level's objectives")
+	}
+}
+
+func TestMissionPlaceKeepsProgress(t *testing.T) {
+	level, _ := findLevel("Cargo Run")
+	mission := newMission(level, 1)
+	mission.place(5)
+	mission.touchDown(0)
+	if !mission.Objectives[0].Loaded {
+		t.Fatal("crate not loaded")
+	}
+	// on a smaller screen the crate still needs delivering, somewhere else
+	mission.place(3)
+	crate := mission.Objectives[0]
+	if !crate.Loaded || crate.Skipped || crate.To != 2 {
+		t.Errorf("after placing again crate is %+v", crate)
+	}
+	mission.touchDown(2)
+	if !mission.Objectives[0].Done || math.Abs(mission.Mass-1) > 1e-9 {
+		t.Errorf("crate not delivered after placing again, mass %.2f", mission.Mass)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+// Emitter describes a kind of particle. Speeds and gravity are in
+// quadrant sub-pixels per frame and scaled to the renderer when the
+// particles are emitted.
+type Emitter struct {
+	// each particle lives between half and all of Life frames
+	Life    float64
+	Speed   float64
+	Spread  float64
+	Gravity float64
+	From    Colour
+	To      Colour
+	// Ramp particles are drawn as glyphs over the screen, young to old,
+	// rather than as sub-pixels in the canvas
+	Ramp []rune
+	// Solid particles die when they fall into the ground
+	Solid bool
+}
+
+// Particle positions are in sub-pixels of the frame canvas.
+type Particle struct {
+	X, Y    float64
+	VX, VY  float64
+	Gravity float64
+	Age     float64
+	Life    float64
+	Emitter *Emitter
+}
+
+var exhaustEmitter = &Emitter{
+	Life:    24,
+	Speed:   0.25,
+	Spread:  0.35,
+	Gravity: 0.002,
+	From:    YELLOW,
+	To:      RED.scale(0.4),
+	Solid:   true,
+}
+
+var explosionEmitter = &Emitter{
+	Life:    300,
+	Speed:   0.08,
+	Spread:  math.Pi,
+	Gravity: 0.0002,
+	From:    YELLOW,
+	To:      RED,
+	Ramp:    []rune{'@', '*', '+', '.'},
+}
+
+var impactEmitter = &Emitter{
+	Life:    60,
+	Speed:   0.15,
+	Spread:  1.0,
+	Gravity: 0.004,
+	From:    GREY,
+	To:      GREY.scale(0.3),
+	Solid:   true,
+}
+
+var dustEmitter = &Emitter{
+	Life:    40,
+	Speed:   0.12,
+	Spread:  0.3,
+	Gravity: 0.001,
+	From:    GREY.scale(0.8),
+	To:      GREY.scale(0.2),
+	Solid:   true,
+}
+
+const maxParticles = 2000
+
+var particles []Particle
+
+func clearParticles() {
+	particles = make([]Particle, 0)
+}
+
+// emit adds count particles at x, y heading in direction, in radians
+// with 0 to the right and down the screen positive, give or take the
+// emitter's spread. scale multiplies the speed and gravity.
+func (e *Emitter) emit(x, y, direction float64, count int, scale float64) {
+	for i := 0; i < count && len(particles) < maxParticles; i++ {
+		angle := direction + (rand.Float64()*2-1)*e.Spread
+		speed := e.Speed * scale * (0.5 + rand.Float64()/2)
+		particles = append(particles, Particle{
+			X:       x,
+			Y:       y,
+			VX:      math.Cos(angle) * speed,
+			VY:      math.Sin(angle) * speed,
+			Gravity: e.Gravity * scale,
+			Life:    e.Life * (0.5 + rand.Float64()/2),
+			Emitter: e,
+		})
+	}
+}
+
+func updateParticles(terrain *Canvas) {
+	alive := particles[:0]
+	for _, p := range particles {
+		p.VY += p.Gravity
+		p.X += p.VX
+		p.Y += p.VY
+		p.Age++
+		if p.Age >= p.Life {
+			continue
+		}
+		if p.Emitter.Solid && p.VY > 0 && terrain.get(int(p.X), int(p.Y)) != 0 {
+			continue
+		}
+		alive = append(alive, p)
+	}
+	particles = alive
+}
+
+func (p Particle) colour() Colour {
+	return blend(p.Emitter.From, p.Emitter.To, p.Age/p.Life)
+}
+
+func drawParticles(c *Canvas) {
+	for _, p := range particles {
+		if p.Emitter.Ramp == nil {
+			c.set(int(p.X), int(p.Y), p.colour())
+		}
+	}
+}
+
+// drawParticleGlyphs draws the ramp particles once the canvas is on the
+// screen, older particles step down the ramp to smaller glyphs.
+func drawParticleGlyphs(s tcell.Screen, c *Canvas) {
+	for _, p := range particles {
+		ramp := p.Emitter.Ramp
+		if ramp == nil || !c.inside(int(p.X), int(p.Y)) {
+			continue
+		}
+		r := ramp[int(p.Age/p.Life*float64(len(ramp)))]
+		style := tcell.StyleDefault.Foreground(p.colour().toColor()).Background(color.Black)
+		s.SetContent(int(p.X)/c.CellW, int(p.Y)/c.CellH, r, nil, style)
+	}
+}
+
+// emitExhaust blows exhaust out of every nozzle, throttle from 0 to 1
+// sets how much and how fast.
+func emitExhaust(ship *ShipDesign, x, y, throttle, scale float64) {
+	if throttle <= 0 {
+		return
+	}
+	for _, nozzle := range ship.Nozzles {
+		count := int(throttle*2*ship.Thrust + rand.Float64())
+		exhaustEmitter.emit(x+nozzle.X+0.5, y+nozzle.Y+1, math.Pi/2, count, scale*(0.5+throttle/2))
+	}
+}
+
+// kickUpDust throws dust sideways off the ground when the engine fires
+// close to it, more the closer the ship is.
+func kickUpDust(terrain *Canvas, x, y, throttle, scale float64) {
+	reach := 6 * terrain.CellH
+	for d := 1; d <= reach; d++ {
+		if terrain.get(int(x), int(y)+d) == 0 {
+			continue
+		}
+		closeness := 1 - float64(d)/float64(reach)
+		count := int(closeness*throttle*3 + rand.Float64())
+		ground := y + float64(d) - 1
+		dustEmitter.emit(x, ground, -0.2, count, scale)
+		dustEmitter.emit(x, ground, math.Pi+0.2, count, scale)
+		return
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell/v3"
+)
+
+// ProfileSettings are the options a player picks from the menu and the
+// hangar, kept with their profile.
+type ProfileSettings struct {
+	Renderer   string
+	Ship       string
+	Background bool
+	HUD        string
+	Trajectory bool
+	Mute       bool
+	Vario      bool
+}
+
+// ProfileStats add up every flight a player has flown.
+type ProfileStats struct {
+	Flights   int
+	Landings  int
+	Crashes   int
+	BestScore float64
+	Frames    int
+}
+
+// Profile is one of the people playing on this machine.
+type Profile struct {
+	Name     string
+	Settings ProfileSettings
+	Stats    ProfileStats
+}
+
+// Profiles are saved together with the one last played.
+type Profiles struct {
+	Current string
+	Players []*Profile
+}
+
+var profiles Profiles
+
+// flagOverrides are the options given on the command line. Those kept
+// in a profile too, -renderer, -hud, -mute and -vario, win over whatever
+// the profile has saved but only for this session. The ship, background
+// and trajectory have no options.
+var flagOverrides = map[string]string{}
+
+// overrideSetting puts a command line option back over a profile's
+// setting, options a profile doesn't keep are left alone.
+func overrideSetting(name, value string) {
+	switch name {
+	case "renderer":
+		settings.Renderer = value
+	case "hud":
+		settings.HUD = value
+	case "mute":
+		settings.Mute = value == "true"
+	case "vario":
+		settings.Vario = value == "true"
+	}
+}
+
+// defaultSettings are the settings before any options or profile
+var defaultSettings = settings
+
+func profileSettings(from Settings) ProfileSettings {
+	return ProfileSettings{
+		Renderer:   from.Renderer,
+		Ship:       from.Ship,
+		Background: from.Background,
+		HUD:        from.HUD,
+		Trajectory: from.Trajectory,
+		Mute:       from.Mute,
+		Vario:      from.Vario,
+	}
+}
+
+// overridden is true while a setting is still as its command line
+// option set it.
+func overridden(name, value string) bool {
+	override, ok := flagOverrides[name]
+	return ok && override == value
+}
+
+// capture keeps the settings with the profile, apart from those still
+// as the command line set them which keep what the profile had.
+func (p *Profile) capture() {
+	saved := p.Settings
+	p.Settings = profileSettings(settings)
+	if overridden("renderer", settings.Renderer) {
+		p.Settings.Renderer = saved.Renderer
+	}
+	if overridden("hud", settings.HUD) {
+		p.Settings.HUD = saved.HUD
+	}
+	if overridden("mute", fmt.Sprint(settings.Mute)) {
+		p.Settings.Mute = saved.Mute
+	}
+	if overridden("vario", fmt.Sprint(settings.Vario)) {
+		p.Settings.Vario = saved.Vario
+	}
+}
+
+func (p *Profile) apply() {
+	settings.Renderer = p.Settings.Renderer
+	settings.Ship = p.Settings.Ship
+	settings.Background = p.Settings.Background
+	settings.HUD = p.Settings.HUD
+	settings.Trajectory = p.Settings.Trajectory
+	settings.Mute = p.Settings.Mute
+	settings.Vario = p.Settings.Vario
+	for name, value := range flagOverrides {
+		overrideSetting(name, value)
+	}
+}
+
+func findProfile(name string) *Profile {
+	for _, p := range profiles.Players {
+		if strings.EqualFold(p.Name, name) {
+			return p
+		}
+	}
+	return nil
+}
+
+// currentProfile is nil until someone has said who they are.
+func currentProfile() *Profile {
+	return findProfile(profiles.Current)
+}
+
+// playerName is who the scores are put down to.
+func playerName() string {
+	if p := currentProfile(); p != nil {
+		return p.Name
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "Player"
+}
+
+// selectProfile switches to the named player, making a new profile with
+// the settings as they are if there isn't one.
+func selectProfile(name string) {
+	if p := currentProfile(); p != nil {
+		p.capture()
+	}
+	p := findProfile(name)
+	if p == nil {
+		p = &Profile{Name: name, Settings: profileSettings(defaultSettings)}
+		p.capture()
+		profiles.Players = append(profiles.Players, p)
+		log.Println("New profile", name)
+	} else {
+		p.apply()
+		log.Println("Switched to profile", p.Name)
+	}
+	profiles.Current = p.Name
+	saveProfiles()
+}
+
+func loadProfiles() {
+	data, err := readStore("profiles")
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &profiles)
+	}
+	if err != nil {
+		log.Println("Profiles not loaded", err)
+		return
+	}
+	if p := currentProfile(); p != nil {
+		p.apply()
+		log.Println("Playing as", p.Name)
+	}
+}
+
+// saveProfiles keeps the current settings with the current player too.
+func saveProfiles() {
+	if settings.Headless {
+		return
+	}
+	if p := currentProfile(); p != nil {
+		p.capture()
+	}
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err == nil {
+		err = writeStore("profiles", data)
+	}
+	if err != nil {
+		log.Println("Profiles not saved", err)
+	}
+}
+
+// recordFlight adds a flight to the current player's stats, one given
+// up on is neither landed nor crashed.
+func recordFlight(landed, crashed bool, score float64, frames int) {
+	p := currentProfile()
+	if p == nil {
+		return
+	}
+	p.Stats.Flights++
+	if landed {
+		p.Stats.Landings++
+	}
+	if crashed {
+		p.Stats.Crashes++
+	}
+	p.Stats.BestScore = max(p.Stats.BestScore, score)
+	p.Stats.Frames += frames
+	saveProfiles()
+}
+
+// askPlayerName gets a name for a new profile or a high score, it keeps
+// the one offered if Escape is pressed.
+func askPlayerName(s tcell.Screen, title string) string {
+	name, _ := runTextEntry(s, title, "Enter your name", playerName(), maxNameLength)
+	return name
+}
+
+func playerLabel() string {
+	return "Player: " + playerName()
+}
+
+func profileLabel(p *Profile) string {
+	minutes := p.Stats.Frames / 60 / 60
+	return fmt.Sprintf("%-10s %3d flights %3d landed best %5.0f %3dm", p.Name, p.Stats.Flights, p.Stats.Landings, p.Stats.BestScore, minutes)
+}
+
+// runProfiles lists the players with their stats to switch between,
+// or a new one to be added.
+func runProfiles(s tcell.Screen) {
+	items := []MenuItem{}
+	for _, p := range profiles.Players {
+		name := p.Name
+		items = append(items, MenuItem{
+			Label:  profileLabel(p),
+			Action: func() { selectProfile(name) },
+		})
+	}
+	items = append(items, MenuItem{
+		Label: "New player",
+		Action: func() {
+			name, ok := runTextEntry(s, "New player", "Enter your name", "", maxNameLength)
+			if ok {
+				selectProfile(name)
+			}
+		},
+	})
+	s.Clear()
+	runMenu(s, "Players", items, nil)
+	s.Clear()
+}
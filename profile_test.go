@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// withOverrides runs a test with the given command line options in
+// force, putting the settings and profiles back after.
+func withOverrides(t *testing.T, overrides map[string]string) {
+	t.Helper()
+	savedSettings, savedProfiles, savedOverrides := settings, profiles, flagOverrides
+	t.Cleanup(func() {
+		settings, profiles, flagOverrides = savedSettings, savedProfiles, savedOverrides
+	})
+	// saveProfiles leaves the disk alone when headless
+	settings.Headless = true
+	profiles = Profiles{}
+	flagOverrides = overrides
+	for name, value := range overrides {
+		overrideSetting(name, value)
+	}
+}
+
+func TestProfileApplyOverrides(t *testing.T) {
+	withOverrides(t, map[string]string{"renderer": "Braille", "mute": "true", "export-dir": "/tmp"})
+	p := &Profile{Name: "Ann", Settings: ProfileSettings{Renderer: "Sextant", HUD: "Side", Mute: false, Vario: true}}
+	p.apply()
+	if settings.Renderer != "Braille" || !settings.Mute {
+		t.Errorf("options given lost to the profile, renderer %s mute %v", settings.Renderer, settings.Mute)
+	}
+	if settings.HUD != "Side" || !settings.Vario {
+		t.Errorf("profile settings not applied, hud %s vario %v", settings.HUD, settings.Vario)
+	}
+	if settings.ExportDir == "/tmp" {
+		t.Error("an option profiles don't keep was applied")
+	}
+}
+
+func TestProfileCaptureLeavesOutOverrides(t *testing.T) {
+	withOverrides(t, map[string]string{"renderer": "Braille", "hud": "Bottom", "mute": "true", "vario": "true"})
+	p := &Profile{Name: "Ann", Settings: ProfileSettings{Renderer: "Sextant", HUD: "Side", Mute: false, Vario: false}}
+	p.apply()
+	p.capture()
+	want := ProfileSettings{Renderer: "Sextant", HUD: "Side", Mute: false, Vario: false}
+	if got := p.Settings; got.Renderer != want.Renderer || got.HUD != want.HUD || got.Mute != want.Mute || got.Vario != want.Vario {
+		t.Errorf("captured %+v, want %+v", got, want)
+	}
+
+	// changed from the menu it is the player's choice and is kept
+	settings.Renderer = "Quadrant"
+	settings.Mute = false
+	p.capture()
+	if p.Settings.Renderer != "Quadrant" || p.Settings.Mute {
+		t.Errorf("menu changes not kept, %+v", p.Settings)
+	}
+	if p.Settings.HUD != "Side" || p.Settings.Vario {
+		t.Errorf("options still in force were kept, %+v", p.Settings)
+	}
+}
+
+func TestProfileSwitchKeepsOverridesOut(t *testing.T) {
+	withOverrides(t, map[string]string{"renderer": "Braille"})
+	selectProfile("Ann")
+	selectProfile("Bob")
+	selectProfile("ann")
+	if profiles.Current != "Ann" || len(profiles.Players) != 2 {
+		t.Fatalf("current %s with %d players", profiles.Current, len(profiles.Players))
+	}
+	// what would be saved
+	data, err := json.Marshal(profiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Braille") {
+		t.Errorf("the command line renderer was saved, %s", data)
+	}
+	if settings.Renderer != "Braille" {
+		t.Errorf("switching player lost the command line renderer, %s", settings.Renderer)
+	}
+}
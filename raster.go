@@ -0,0 +1,182 @@
+package main
+
+import (
+	"math"
+)
+
+// Point is a position on a canvas in sub-pixels.
+type Point struct {
+	X, Y float64
+}
+
+// drawLine uses Bresenham's algorithm so lines of any slope join up,
+// both end points are drawn and anything off the canvas is clipped.
+func drawLine(c *Canvas, x1, y1, x2, y2 float64, colour Colour) {
+	x, y := int(x1), int(y1)
+	endX, endY := int(x2), int(y2)
+	dx := absInt(endX - x)
+	dy := -absInt(endY - y)
+	stepX, stepY := 1, 1
+	if endX < x {
+		stepX = -1
+	}
+	if endY < y {
+		stepY = -1
+	}
+	err := dx + dy
+	for {
+		c.set(x, y, colour)
+		if x == endX && y == endY {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += stepX
+		}
+		if e2 <= dx {
+			err += dx
+			y += stepY
+		}
+	}
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// drawEllipse draws the outline of an ellipse, with rx equal to ry it is
+// a circle.
+func drawEllipse(c *Canvas, cx, cy, rx, ry float64, colour Colour) {
+	if rx <= 0 || ry <= 0 {
+		c.set(int(cx), int(cy), colour)
+		return
+	}
+	// one quarter is worked out and mirrored into the others so the
+	// outline is symmetric, with enough steps that neighbouring points touch
+	steps := int(math.Ceil(math.Pi/2*math.Max(rx, ry))) + 1
+	quarters := [4][2]float64{{1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+	oldX, oldY := rx, 0.0
+	for i := 1; i <= steps; i++ {
+		angle := math.Pi / 2 * float64(i) / float64(steps)
+		x := math.Round(rx * math.Cos(angle))
+		y := math.Round(ry * math.Sin(angle))
+		for _, q := range quarters {
+			drawLine(c, cx+q[0]*math.Round(oldX), cy+q[1]*math.Round(oldY), cx+q[0]*x, cy+q[1]*y, colour)
+		}
+		oldX, oldY = x, y
+	}
+}
+
+func drawCircle(c *Canvas, cx, cy, r float64, colour Colour) {
+	drawEllipse(c, cx, cy, r, r, colour)
+}
+
+// fillEllipse fills every sub-pixel whose centre is inside the ellipse.
+// A colour of 0 clears the area, which is how craters are dug.
+func fillEllipse(c *Canvas, cx, cy, rx, ry float64, colour Colour) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	for y := int(cy - ry); y <= int(cy+ry); y++ {
+		for x := int(cx - rx); x <= int(cx+rx); x++ {
+			dx := (float64(x) + 0.5 - cx) / rx
+			dy := (float64(y) + 0.5 - cy) / ry
+			if dx*dx+dy*dy <= 1 {
+				c.set(x, y, colour)
+			}
+		}
+	}
+}
+
+func fillCircle(c *Canvas, cx, cy, r float64, colour Colour) {
+	fillEllipse(c, cx, cy, r, r, colour)
+}
+
+// fillPolygon scan converts a polygon with the even-odd rule, so the
+// points can go either way round and need not be convex.
+func fillPolygon(c *Canvas, points []Point, colour Colour) {
+	if len(points) < 3 {
+		return
+	}
+	minY, maxY := points[0].Y, points[0].Y
+	for _, p := range points {
+		minY = math.Min(minY, p.Y)
+		maxY = math.Max(maxY, p.Y)
+	}
+	crossings := make([]float64, 0, len(points))
+	for y := int(math.Floor(minY)); y <= int(math.Ceil(maxY)); y++ {
+		scan := float64(y) + 0.5
+		crossings = crossings[:0]
+		for i := range points {
+			a := points[i]
+			b := points[(i+1)%len(points)]
+			if (a.Y <= scan) != (b.Y <= scan) {
+				crossings = append(crossings, a.X+(scan-a.Y)/(b.Y-a.Y)*(b.X-a.X))
+			}
+		}
+		sortFloats(crossings)
+		for i := 0; i+1 < len(crossings); i += 2 {
+			for x := int(math.Ceil(crossings[i] - 0.5)); x <= int(math.Floor(crossings[i+1]-0.5)); x++ {
+				c.set(x, y, colour)
+			}
+		}
+	}
+}
+
+// sortFloats is an insertion sort, polygons only have a handful of
+// crossings per row.
+func sortFloats(values []float64) {
+	for i := 1; i < len(values); i++ {
+		for j := i; j > 0 && values[j] < values[j-1]; j-- {
+			values[j], values[j-1] = values[j-1], values[j]
+		}
+	}
+}
+
+// Sprite is a small picture blitted onto a canvas, empty sub-pixels are
+// transparent. OriginX and OriginY are the sub-pixel drawn at the
+// position given to blit.
+type Sprite struct {
+	W       int
+	H       int
+	OriginX int
+	OriginY int
+	Pix     []Colour
+}
+
+// newSprite builds a sprite from rows of text, each rune looked up in
+// colours and anything not listed left transparent.
+func newSprite(rows []string, colours map[rune]Colour, originX, originY int) *Sprite {
+	sprite := &Sprite{H: len(rows), OriginX: originX, OriginY: originY}
+	for _, row := range rows {
+		sprite.W = max(sprite.W, len([]rune(row)))
+	}
+	sprite.Pix = make([]Colour, sprite.W*sprite.H)
+	for y, row := range rows {
+		for x, r := range []rune(row) {
+			sprite.Pix[y*sprite.W+x] = colours[r]
+		}
+	}
+	return sprite
+}
+
+func (sprite *Sprite) at(x, y int) Colour {
+	if x < 0 || x >= sprite.W || y < 0 || y >= sprite.H {
+		return 0
+	}
+	return sprite.Pix[y*sprite.W+x]
+}
+
+func blit(c *Canvas, sprite *Sprite, x, y int) {
+	for sy := 0; sy < sprite.H; sy++ {
+		for sx := 0; sx < sprite.W; sx++ {
+			if colour := sprite.at(sx, sy); colour != 0 {
+				c.set(x-sprite.OriginX+sx, y-sprite.OriginY+sy, colour)
+			}
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func countSet(c *Canvas) int {
+	count := 0
+	for y := range c.Pix {
+		for _, colour := range c.Pix[y] {
+			if colour != 0 {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func sameCanvas(a, b *Canvas) bool {
+	for y := range a.Pix {
+		for x := range a.Pix[y] {
+			if a.Pix[y][x] != b.Pix[y][x] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestDrawLineOctants(t *testing.T) {
+	ends := []Point{
+		{18, 13}, {13, 18}, {7, 18}, {2, 13},
+		{2, 7}, {7, 2}, {13, 2}, {18, 7},
+	}
+	for _, end := range ends {
+		for _, reverse := range []bool{false, true} {
+			c := newCanvas(21, 21, 1, 1)
+			from, to := Point{10, 10}, end
+			if reverse {
+				from, to = to, from
+			}
+			drawLine(c, from.X, from.Y, to.X, to.Y, RED)
+			if c.get(int(from.X), int(from.Y)) != RED || c.get(int(to.X), int(to.Y)) != RED {
+				t.Errorf("line %v to %v is missing an end point", from, to)
+			}
+			// one sub-pixel for every step along the longer axis
+			steps := max(absInt(int(to.X-from.X)), absInt(int(to.Y-from.Y)))
+			if got := countSet(c); got != steps+1 {
+				t.Errorf("line %v to %v set %d sub-pixels, want %d", from, to, got, steps+1)
+			}
+		}
+	}
+}
+
+func TestDrawLineJoinsUp(t *testing.T) {
+	c := newCanvas(21, 21, 1, 1)
+	drawLine(c, 1, 3, 19, 17, RED)
+	// every column between the ends has exactly one sub-pixel set
+	for x := 1; x <= 19; x++ {
+		set := 0
+		for y := 0; y < c.Height; y++ {
+			if c.get(x, y) != 0 {
+				set++
+			}
+		}
+		if set != 1 {
+			t.Errorf("column %d has %d sub-pixels set, want 1", x, set)
+		}
+	}
+}
+
+func TestDrawLineClipped(t *testing.T) {
+	c := newCanvas(21, 21, 1, 1)
+	drawLine(c, -5, -5, 30, 30, RED)
+	if c.get(0, 0) != RED || c.get(20, 20) != RED {
+		t.Error("clipped diagonal should reach both corners")
+	}
+	if got := countSet(c); got != 21 {
+		t.Errorf("clipped diagonal set %d sub-pixels, want 21", got)
+	}
+
+	c = newCanvas(21, 21, 1, 1)
+	drawLine(c, -10, -3, -1, 30, RED)
+	if got := countSet(c); got != 0 {
+		t.Errorf("line off the canvas set %d sub-pixels", got)
+	}
+}
+
+func TestDrawCircle(t *testing.T) {
+	const r = 6
+	c := newCanvas(21, 21, 1, 1)
+	drawCircle(c, 10, 10, r, RED)
+	for _, p := range []Point{{10 + r, 10}, {10 - r, 10}, {10, 10 + r}, {10, 10 - r}} {
+		if c.get(int(p.X), int(p.Y)) != RED {
+			t.Errorf("circle misses %v", p)
+		}
+	}
+	for y := range c.Pix {
+		for x, colour := range c.Pix[y] {
+			if colour == 0 {
+				continue
+			}
+			d := math.Hypot(float64(x-10), float64(y-10))
+			if math.Abs(d-r) > 1 {
+				t.Errorf("circle point %d,%d is %.2f from the centre", x, y, d)
+			}
+		}
+	}
+	if c.get(10, 10) != 0 {
+		t.Error("circle outline filled its centre")
+	}
+}
+
+func TestDrawEllipse(t *testing.T) {
+	c := newCanvas(21, 21, 1, 1)
+	drawEllipse(c, 10, 10, 8, 4, RED)
+	for _, p := range []Point{{18, 10}, {2, 10}, {10, 14}, {10, 6}} {
+		if c.get(int(p.X), int(p.Y)) != RED {
+			t.Errorf("ellipse misses %v", p)
+		}
+	}
+	for y := range c.Pix {
+		for x, colour := range c.Pix[y] {
+			if colour == 0 {
+				continue
+			}
+			if y < 6 || y > 14 || x < 2 || x > 18 {
+				t.Errorf("ellipse point %d,%d outside its radii", x, y)
+			}
+			// the outline is mirrored left to right and top to bottom
+			if c.get(20-x, y) == 0 || c.get(x, 20-y) == 0 {
+				t.Errorf("ellipse point %d,%d has no mirror", x, y)
+			}
+		}
+	}
+}
+
+func TestFillCircle(t *testing.T) {
+	const r = 7
+	c := newCanvas(21, 21, 1, 1)
+	// centred on a corner so every sub-pixel centre has a mirror image
+	fillCircle(c, 10.5, 10.5, r, RED)
+	for y := range c.Pix {
+		for x, colour := range c.Pix[y] {
+			d := math.Hypot(float64(x)+0.5-10.5, float64(y)+0.5-10.5)
+			if (colour != 0) != (d <= r) {
+				t.Errorf("sub-pixel %d,%d at %.2f filled %v", x, y, d, colour != 0)
+			}
+			if colour != c.get(20-x, y) || colour != c.get(x, 20-y) {
+				t.Errorf("fill at %d,%d is not symmetric", x, y)
+			}
+		}
+	}
+	area := math.Pi * r * r
+	if got := float64(countSet(c)); math.Abs(got-area) > area*0.1 {
+		t.Errorf("filled %v sub-pixels, want about %.0f", got, area)
+	}
+}
+
+func TestFillEllipse(t *testing.T) {
+	c := newCanvas(21, 21, 1, 1)
+	fillEllipse(c, 10.5, 10.5, 8, 3, RED)
+	if c.get(3, 10) != RED || c.get(17, 10) != RED || c.get(10, 8) != RED || c.get(10, 12) != RED {
+		t.Error("ellipse fill doesn't reach its radii")
+	}
+	if c.get(1, 10) != 0 || c.get(19, 10) != 0 || c.get(10, 6) != 0 || c.get(10, 14) != 0 {
+		t.Error("ellipse fill goes past its radii")
+	}
+	// a colour of 0 digs the ellipse back out
+	fillEllipse(c, 10.5, 10.5, 8, 3, 0)
+	if got := countSet(c); got != 0 {
+		t.Errorf("clearing left %d sub-pixels set", got)
+	}
+}
+
+func TestFillPolygonConcave(t *testing.T) {
+	// a square with a notch cut up into the bottom edge
+	shape := []Point{{2, 2}, {18, 2}, {18, 18}, {10, 10}, {2, 18}}
+	reversed := make([]Point, len(shape))
+	for i, p := range shape {
+		reversed[len(shape)-1-i] = p
+	}
+
+	c := newCanvas(21, 21, 1, 1)
+	fillPolygon(c, shape, RED)
+	for _, p := range []Point{{10, 4}, {3, 16}, {17, 16}, {2, 2}} {
+		if c.get(int(p.X), int(p.Y)) != RED {
+			t.Errorf("polygon should fill %v", p)
+		}
+	}
+	for _, p := range []Point{{10, 16}, {10, 12}, {1, 10}, {19, 10}, {10, 19}} {
+		if c.get(int(p.X), int(p.Y)) != 0 {
+			t.Errorf("polygon shouldn't fill %v", p)
+		}
+	}
+
+	other := newCanvas(21, 21, 1, 1)
+	fillPolygon(other, reversed, RED)
+	if !sameCanvas(c, other) {
+		t.Error("polygon fills differently wound the other way")
+	}
+}
+
+func TestBlit(t *testing.T) {
+	sprite := newSprite([]string{
+		"A.",
+		".A",
+	}, map[rune]Colour{'A': RED}, 1, 1)
+	c := newCanvas(10, 10, 1, 1)
+	c.set(5, 4, BLUE)
+	c.set(4, 5, BLUE)
+	blit(c, sprite, 5, 5)
+	// the origin sub-pixel lands on the position given
+	if c.get(5, 5) != RED || c.get(4, 4) != RED {
+		t.Error("sprite not drawn around its origin")
+	}
+	// transparent sub-pixels leave what was underneath
+	if c.get(5, 4) != BLUE || c.get(4, 5) != BLUE {
+		t.Error("transparent sub-pixels overwrote the canvas")
+	}
+	if got := countSet(c); got != 4 {
+		t.Errorf("%d sub-pixels set, want 4", got)
+	}
+
+	// partly off the canvas is clipped rather than wrapping
+	c = newCanvas(10, 10, 1, 1)
+	blit(c, sprite, 0, 0)
+	if c.get(0, 0) != RED || countSet(c) != 1 {
+		t.Error("sprite off the edge not clipped")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+)
+
+// Replay is everything needed to fly a flight again frame for frame.
+// The physics steps once a frame and the only randomness that matters
+// is the meteors, so the pilot's inputs and the meteor seed are enough
+// as long as the screen size and renderer match.
+type Replay struct {
+	Level    string
+	Ship     string
+	Renderer string
+	Width    int
+	Height   int
+	Seed     int64
+	Inputs   []ReplayInput
+	// frames the terminal was resized on, the moon is rebuilt then
+	Resizes []int
+	// End is the last frame shown and Ending why the flight stopped
+	// there, escape, crash, landing or export. Older replays have none.
+	End    int    `json:",omitempty"`
+	Ending string `json:",omitempty"`
+}
+
+// ReplayInput is recorded only for frames where the pilot did something.
+type ReplayInput struct {
+	Frame  int
+	Thrust bool    `json:",omitempty"`
+	MoveX  float64 `json:",omitempty"`
+}
+
+// playback, when set, flies the game instead of the keyboard
+var playback *Replay
+
+func (r *Replay) record(frame int, thrust bool, moveX float64) {
+	if thrust || moveX != 0 {
+		r.Inputs = append(r.Inputs, ReplayInput{Frame: frame, Thrust: thrust, MoveX: moveX})
+	}
+}
+
+// finish marks where the flight stopped so playback stops there too.
+func (r *Replay) finish(frame int, ending string) {
+	r.End = frame
+	r.Ending = ending
+}
+
+// over is true once playback has gone past the recorded end.
+func (r *Replay) over(frame int) bool {
+	return r.End > 0 && frame > r.End
+}
+
+// input gives the pilot's input for a frame, next is where to start
+// looking and is moved on past it.
+func (r *Replay) input(frame int, next *int) (bool, float64) {
+	for *next < len(r.Inputs) && r.Inputs[*next].Frame < frame {
+		*next++
+	}
+	if *next < len(r.Inputs) && r.Inputs[*next].Frame == frame {
+		in := r.Inputs[*next]
+		return in.Thrust, in.MoveX
+	}
+	return false, 0
+}
+
+func (r *Replay) resizedAt(frame int) bool {
+	for _, resize := range r.Resizes {
+		if resize == frame {
+			return true
+		}
+	}
+	return false
+}
+
+func saveReplay(path string, r *Replay) {
+	data, err := json.Marshal(r)
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		log.Println("Replay not saved", err)
+		return
+	}
+	log.Println("Replay saved", path, len(r.Inputs), "inputs")
+}
+
+func loadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayInputSteps(t *testing.T) {
+	r := &Replay{}
+	for frame := 1; frame <= 10; frame++ {
+		thrust := frame%3 == 0
+		moveX := 0.0
+		if frame == 5 || frame == 6 {
+			moveX = -1
+		}
+		r.record(frame, thrust, moveX)
+	}
+	// only frames where something happened are kept
+	if len(r.Inputs) != 4 {
+		t.Fatalf("recorded %d inputs, want 4", len(r.Inputs))
+	}
+	next := 0
+	for frame := 1; frame <= 12; frame++ {
+		thrust, moveX := r.input(frame, &next)
+		wantThrust := frame <= 10 && frame%3 == 0
+		wantMoveX := 0.0
+		if frame == 5 || frame == 6 {
+			wantMoveX = -1
+		}
+		if thrust != wantThrust || moveX != wantMoveX {
+			t.Errorf("frame %d gave %v %v, want %v %v", frame, thrust, moveX, wantThrust, wantMoveX)
+		}
+	}
+}
+
+func TestReplayInputSkipsFrames(t *testing.T) {
+	r := &Replay{Inputs: []ReplayInput{{Frame: 2, Thrust: true}, {Frame: 4, MoveX: 1}, {Frame: 9, Thrust: true}}}
+	next := 0
+	// frames resized on are skipped so input can be asked for out of step
+	if thrust, _ := r.input(3, &next); thrust {
+		t.Error("frame 3 has no input")
+	}
+	if _, moveX := r.input(4, &next); moveX != 1 {
+		t.Error("frame 4 lost its input after skipping frame 2")
+	}
+	if thrust, _ := r.input(9, &next); !thrust {
+		t.Error("frame 9 lost its input")
+	}
+}
+
+func TestReplayOver(t *testing.T) {
+	r := &Replay{Resizes: []int{7}}
+	if r.over(100000) {
+		t.Error("a replay with no end recorded should never be over")
+	}
+	r.finish(50, "crash")
+	if r.over(50) || !r.over(51) {
+		t.Error("replay should play frame 50 and stop after it")
+	}
+	if !r.resizedAt(7) || r.resizedAt(8) {
+		t.Error("resize frames not found")
+	}
+}
+
+func TestReplaySaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight.json")
+	r := &Replay{Level: "Easy", Ship: "Hopper", Renderer: "Quadrant", Width: 80, Height: 24, Seed: 42, Resizes: []int{3}}
+	r.record(2, true, 0)
+	r.finish(99, "landing")
+	saveReplay(path, r)
+	loaded, err := loadReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Seed != 42 || loaded.End != 99 || loaded.Ending != "landing" || len(loaded.Inputs) != 1 || !loaded.resizedAt(3) {
+		t.Errorf("loaded %+v", loaded)
+	}
+}
+
+// flyReplay flies r without a terminal, recording it again to path.
+func flyReplay(t *testing.T, r *Replay, path string) *Replay {
+	t.Helper()
+	saved, savedPlayback, savedFlights := settings, playback, flights
+	defer func() {
+		settings, playback, flights = saved, savedPlayback, savedFlights
+	}()
+	// each is the first flight so it saves to path
+	flights = 0
+	settings.Headless = true
+	settings.Record = path
+	settings.Renderer = r.Renderer
+	playback = r
+	s, err := newHeadlessScreen(r.Width, r.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Fini()
+	level, _ := findLevel(r.Level)
+	runGame(s, level, findShip(r.Ship))
+	again, err := loadReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return again
+}
+
+func TestReplayEndsWhereRecorded(t *testing.T) {
+	if testing.Short() {
+		t.Skip("flies whole flights")
+	}
+	dir := t.TempDir()
+	// nobody at the controls, it falls until it crashes
+	first := flyReplay(t, &Replay{Level: "Easy", Ship: "Eagle", Renderer: "Quadrant", Width: 80, Height: 24, Seed: 7}, filepath.Join(dir, "first.json"))
+	if first.Ending != "crash" || first.End == 0 {
+		t.Fatalf("first flight ended with %q at %d", first.Ending, first.End)
+	}
+	// the same again ends on the same frame the same way
+	second := flyReplay(t, first, filepath.Join(dir, "second.json"))
+	if second.End != first.End || second.Ending != first.Ending {
+		t.Errorf("replay ended with %s at %d, recorded %s at %d", second.Ending, second.End, first.Ending, first.End)
+	}
+	// one left with Escape stops on its last frame before the crash
+	escaped := *first
+	escaped.finish(first.End/2, "escape")
+	third := flyReplay(t, &escaped, filepath.Join(dir, "third.json"))
+	if third.End != escaped.End || third.Ending != "escape" {
+		t.Errorf("escaped replay ended with %s at %d, want escape at %d", third.Ending, third.End, escaped.End)
+	}
+}
+
+func TestFlightPath(t *testing.T) {
+	saved := flights
+	defer func() { flights = saved }()
+	for _, test := range []struct {
+		flight     int
+		path, want string
+	}{
+		{1, "flight.json", "flight.json"},
+		{2, "flight.json", "flight-2.json"},
+		{3, "out/flight.cast", "out/flight-3.cast"},
+		{2, "flight", "flight-2"},
+	} {
+		flights = test.flight
+		if got := flightPath(test.path); got != test.want {
+			t.Errorf("flight %d of %s saves to %s, want %s", test.flight, test.path, got, test.want)
+		}
+	}
+}
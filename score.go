@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+// the most each part of the score can be worth
+const (
+	fuelBonus     = 500
+	softBonus     = 500
+	accuracyBonus = 300
+	timeBonus     = 300
+	ascentBonus   = 500
+	// timeBonus runs down by this much a second of flight
+	timeBonusRate = 5
+	meteorPenalty = 250
+)
+
+// ScoreItem is one line of the breakdown, Detail saying where the points
+// came from.
+type ScoreItem struct {
+	Name   string
+	Detail string
+	Points float64
+}
+
+// Score is added up from the bonuses, multiplied by the pad and then the
+// penalties taken off. It never goes below nothing.
+type Score struct {
+	Bonuses    []ScoreItem
+	Multiplier float64
+	Pad        string
+	Penalties  []ScoreItem
+}
+
+// Touchdown is what the landing is scored on, speeds as shown on the
+// instruments.
+type Touchdown struct {
+	Fuel      float64
+	MaxFuel   float64
+	Speed     float64
+	SafeSpeed float64
+	Pad       LandingCoOrds
+	X         float64
+	Frames    float64
+	Hits      int
+	Ship      *ShipDesign
+}
+
+// padMultiplier rewards the narrower pads, measured in ship widths.
+func padMultiplier(pad LandingCoOrds, ship *ShipDesign) (float64, string) {
+	widths := float64(pad.End-pad.Start) / float64(ship.Collision.W)
+	switch {
+	case widths <= 2:
+		return 3, "narrow pad"
+	case widths <= 4:
+		return 2, "small pad"
+	}
+	return 1, "wide pad"
+}
+
+func scoreLanding(t Touchdown) Score {
+	fuel := t.Fuel / t.MaxFuel
+	softness := math.Max(0, 1-t.Speed/t.SafeSpeed)
+	centre := float64(t.Pad.Start+t.Pad.End) / 2
+	halfWidth := math.Max(1, float64(t.Pad.End-t.Pad.Start)/2)
+	offset := math.Min(1, math.Abs(t.X-centre)/halfWidth)
+	seconds := t.Frames / 60
+	score := Score{
+		Bonuses: []ScoreItem{
+			{Name: "Fuel bonus", Detail: fmt.Sprintf("%.0f%% left", fuel*100), Points: math.Round(fuel * fuelBonus)},
+			{Name: "Soft landing", Detail: fmt.Sprintf("%.1f of %.1f", t.Speed, t.SafeSpeed), Points: math.Round(softness * softBonus)},
+			{Name: "Pad accuracy", Detail: fmt.Sprintf("%.0f%% off centre", offset*100), Points: math.Round((1 - offset) * accuracyBonus)},
+			{Name: "Time bonus", Detail: fmt.Sprintf("%.0fs", seconds), Points: math.Max(0, math.Round(timeBonus-seconds*timeBonusRate))},
+		},
+	}
+	score.Multiplier, score.Pad = padMultiplier(t.Pad, t.Ship)
+	score.setHits(t.Hits)
+	return score
+}
+
+// setHits replaces the meteor penalty, hits can still come after landing
+// on missions with an ascent.
+func (s *Score) setHits(hits int) {
+	s.Penalties = nil
+	if hits > 0 {
+		s.Penalties = append(s.Penalties, ScoreItem{Name: "Meteor hits", Detail: fmt.Sprintf("%d x %d", hits, meteorPenalty), Points: -float64(hits * meteorPenalty)})
+	}
+}
+
+// addAscent scores getting back up, the gentler the docking the more
+// the fuel left is worth. Speeds are as shown on the instruments.
+func (s *Score) addAscent(fuel, dockSpeed, safeSpeed float64) {
+	gentle := math.Max(0, 2-dockSpeed/safeSpeed)
+	s.Bonuses = append(s.Bonuses, ScoreItem{
+		Name:   "Ascent",
+		Detail: fmt.Sprintf("%.0f%% fuel, docked at %.1f", fuel/ascentFuel*100, dockSpeed),
+		Points: math.Round(fuel / ascentFuel * ascentBonus * gentle / 2),
+	})
+}
+
+func (s Score) subtotal() float64 {
+	total := 0.0
+	for _, item := range s.Bonuses {
+		total += item.Points
+	}
+	return total
+}
+
+func (s Score) total() float64 {
+	total := s.subtotal() * s.Multiplier
+	for _, item := range s.Penalties {
+		total += item.Points
+	}
+	return math.Max(0, total)
+}
+
+// lines are the breakdown as it is read out, the running total after
+// each one.
+func (s Score) lines() ([]string, []float64) {
+	lines := []string{}
+	running := []float64{}
+	add := func(name, detail string, points, total float64) {
+		lines = append(lines, fmt.Sprintf("%-14s %-26s %+7.0f", name, detail, points))
+		running = append(running, total)
+	}
+	total := 0.0
+	for _, item := range s.Bonuses {
+		total += item.Points
+		add(item.Name, item.Detail, item.Points, total)
+	}
+	multiplied := total * s.Multiplier
+	add("Pad multiplier", fmt.Sprintf("x%.0f %s", s.Multiplier, s.Pad), multiplied-total, multiplied)
+	total = multiplied
+	for _, item := range s.Penalties {
+		total += item.Points
+		add(item.Name, item.Detail, item.Points, math.Max(0, total))
+	}
+	return lines, running
+}
+
+// scoreTallyStep is the pause between lines being added up
+const scoreTallyStep = 400 * time.Millisecond
+
+func displayScore(s tcell.Screen, title string, score Score, shown int) {
+	styleTitle := tcell.StyleDefault.Foreground(color.Yellow).Background(color.Black)
+	styleNormal := tcell.StyleDefault.Foreground(color.White).Background(color.Black)
+	styleTotal := tcell.StyleDefault.Foreground(color.Green).Background(color.Black)
+
+	lines, running := score.lines()
+	width, height := s.Size()
+	boxWidth := len(lines[0]) + 8
+	boxHeight := len(lines) + 9
+	boxX := (width - boxWidth) / 2
+	boxY := (height - boxHeight) / 2
+	drawBox(s, boxX, boxY, boxWidth, boxHeight, styleNormal)
+
+	y := boxY + 2
+	drawTextCentre(s, width, y, styleTitle, title)
+	for i := 0; i < shown && i < len(lines); i++ {
+		drawText(s, boxX+4, y+2+i, boxX+boxWidth, y+2+i, styleNormal, lines[i])
+	}
+	total := 0.0
+	if shown > 0 {
+		total = running[min(shown, len(running))-1]
+	}
+	drawText(s, boxX+4, y+3+len(lines), boxX+boxWidth, y+3+len(lines), styleTotal, fmt.Sprintf("%-41s %7.0f", "Total", total))
+	if shown >= len(lines) {
+		drawTextCentre(s, width, y+5+len(lines), styleTitle, "Press Enter")
+	}
+}
+
+// runScoreBreakdown adds the score up a line at a time, any key shows
+// the lot and Enter or Escape carries on once it is all there.
+func runScoreBreakdown(s tcell.Screen, title string, score Score) {
+	lines, _ := score.lines()
+	shown := 0
+	tick := time.NewTicker(scoreTallyStep)
+	defer tick.Stop()
+
+	s.Clear()
+	for {
+		displayScore(s, title, score, shown)
+		s.Show()
+
+		select {
+		case <-tick.C:
+			if shown < len(lines) {
+				shown++
+				playSound(SoundMenuMove)
+			}
+		case ev := <-s.EventQ():
+			switch ev := ev.(type) {
+			case *tcell.EventResize:
+				s.Sync()
+				s.Clear()
+			case *tcell.EventKey:
+				done := shown >= len(lines)
+				shown = len(lines)
+				if done && (ev.Key() == tcell.KeyEnter || ev.Key() == tcell.KeyEscape) {
+					s.Clear()
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func touchdown() Touchdown {
+	return Touchdown{
+		Fuel:      50,
+		MaxFuel:   100,
+		Speed:     1,
+		SafeSpeed: 4,
+		Pad:       LandingCoOrds{Start: 10, End: 40},
+		X:         25,
+		Frames:    600,
+		Ship:      findShip("Eagle"),
+	}
+}
+
+func TestScoreLanding(t *testing.T) {
+	score := scoreLanding(touchdown())
+	want := map[string]float64{
+		"Fuel bonus":   250,
+		"Soft landing": 375,
+		"Pad accuracy": 300,
+		"Time bonus":   250,
+	}
+	for _, item := range score.Bonuses {
+		if item.Points != want[item.Name] {
+			t.Errorf("%s scored %.0f, want %.0f", item.Name, item.Points, want[item.Name])
+		}
+	}
+	// 30 sub-pixels is 10 Eagle widths
+	if score.Multiplier != 1 || len(score.Penalties) != 0 {
+		t.Errorf("multiplier %.0f penalties %v", score.Multiplier, score.Penalties)
+	}
+	if score.total() != 1175 {
+		t.Errorf("total %.0f, want 1175", score.total())
+	}
+}
+
+func TestPadMultiplier(t *testing.T) {
+	ship := findShip("Eagle")
+	for _, test := range []struct {
+		width int
+		want  float64
+	}{{6, 3}, {7, 2}, {12, 2}, {13, 1}} {
+		if got, _ := padMultiplier(LandingCoOrds{Start: 0, End: test.width}, ship); got != test.want {
+			t.Errorf("pad %d wide multiplies by %.0f, want %.0f", test.width, got, test.want)
+		}
+	}
+}
+
+func TestScoreLinesAddUp(t *testing.T) {
+	narrow := touchdown()
+	narrow.Pad = LandingCoOrds{Start: 20, End: 26}
+	narrow.Hits = 1
+	ascended := scoreLanding(touchdown())
+	ascended.addAscent(ascentFuel/2, 1, 4)
+	for _, score := range []Score{scoreLanding(touchdown()), scoreLanding(narrow), ascended} {
+		lines, running := score.lines()
+		if len(lines) != len(running) {
+			t.Fatalf("%d lines with %d running totals", len(lines), len(running))
+		}
+		// bonuses, the multiplier and a line for each penalty
+		if want := len(score.Bonuses) + 1 + len(score.Penalties); len(lines) != want {
+			t.Errorf("%d lines, want %d", len(lines), want)
+		}
+		if last := running[len(running)-1]; math.Abs(last-score.total()) > 1e-9 {
+			t.Errorf("breakdown ends on %.0f, total is %.0f", last, score.total())
+		}
+	}
+}
+
+func TestScorePenaltyClamped(t *testing.T) {
+	landing := touchdown()
+	landing.Hits = 20
+	score := scoreLanding(landing)
+	if score.total() != 0 {
+		t.Errorf("total %.0f with %d hits, want 0", score.total(), landing.Hits)
+	}
+	_, running := score.lines()
+	for i, total := range running {
+		if total < 0 {
+			t.Errorf("running total %d is %.0f", i, total)
+		}
+	}
+	// hits after landing replace the penalty rather than adding to it
+	score.setHits(1)
+	if len(score.Penalties) != 1 || score.Penalties[0].Points != -meteorPenalty {
+		t.Errorf("penalties after setHits %v", score.Penalties)
+	}
+	score.setHits(0)
+	if len(score.Penalties) != 0 {
+		t.Errorf("penalties left with no hits %v", score.Penalties)
+	}
+}
+
+func TestScoreAscent(t *testing.T) {
+	score := Score{Multiplier: 1}
+	// a full tank docked at the safe speed is worth half the bonus
+	score.addAscent(ascentFuel, 4, 4)
+	if score.Bonuses[0].Points != ascentBonus/2 {
+		t.Errorf("ascent scored %.0f, want %d", score.Bonuses[0].Points, ascentBonus/2)
+	}
+	// docking at a standstill is worth all of it
+	score = Score{Multiplier: 1}
+	score.addAscent(ascentFuel, 0, 4)
+	if score.total() != ascentBonus {
+		t.Errorf("ascent scored %.0f, want %d", score.total(), ascentBonus)
+	}
+}
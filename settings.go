@@ -0,0 +1,95 @@
+package main
+
+import "strings"
+
+type Settings struct {
+	Renderer   string
+	ASCII      bool
+	Ship       string
+	Background bool
+	HUD        string
+	Trajectory bool
+	Mute       bool
+	Vario      bool
+	NoIntro    bool
+	LogoText   string
+	FontFile   string
+	// the attract scenes in order, each with an optional time
+	Attract   string
+	ExportDir string
+	// a level named here is flown without a terminal and exported
+	ExportLevel  string
+	ExportFrame  int
+	ExportWidth  int
+	ExportHeight int
+	// fly without a terminal, needs a replay to fly
+	Headless   bool
+	Record     string
+	Replay     string
+	GIF        string
+	GIFScale   int
+	GIFSkip    int
+	GIFPalette string
+	Cast       string
+}
+
+var settings = Settings{
+	Renderer:     "Quadrant",
+	Ship:         "Eagle",
+	Background:   true,
+	HUD:          "Top",
+	Trajectory:   true,
+	LogoText:     "BERNIESOFT",
+	Attract:      "logo,demo,scores,credits",
+	ExportDir:    ".",
+	ExportFrame:  60,
+	ExportWidth:  80,
+	ExportHeight: 24,
+	GIFScale:     1,
+	GIFSkip:      4,
+	GIFPalette:   "adaptive",
+}
+
+func rendererLabel() string {
+	return "Display: " + findRenderer(settings.Renderer).Name
+}
+
+// nextRenderer cycles the display setting through the renderers.
+func nextRenderer() {
+	current := findRenderer(settings.Renderer)
+	for i, r := range renderers {
+		if r == current {
+			settings.Renderer = renderers[(i+1)%len(renderers)].Name
+			return
+		}
+	}
+}
+
+func backgroundLabel() string {
+	if settings.Background {
+		return "Background: On"
+	}
+	return "Background: Off"
+}
+
+func trajectoryLabel() string {
+	if settings.Trajectory {
+		return "Trajectory assist: On"
+	}
+	return "Trajectory assist: Off"
+}
+
+func hudLabel() string {
+	return "HUD: " + settings.HUD
+}
+
+// nextHUD cycles the instrument panel between its placements.
+func nextHUD() {
+	for i, placement := range hudPlacements {
+		if strings.EqualFold(placement, settings.HUD) {
+			settings.HUD = hudPlacements[(i+1)%len(hudPlacements)]
+			return
+		}
+	}
+	settings.HUD = hudPlacements[0]
+}
@@ -0,0 +1,150 @@
+package main
+
+import "strings"
+
+// ShipDesign describes a lander. The sprite, nozzles and collision mask
+// share the same origin, the middle of the bottom row, which is where
+// the ship position is. Nozzles are offsets from the origin that the
+// flame comes out below. The bottom row of the collision mask is the
+// feet that have to be on the pad to land. ASCII is the ship drawn in
+// characters for ASCII mode, its bottom row centred on the ship.
+type ShipDesign struct {
+	Name         string
+	Description  string
+	Sprite       *Sprite
+	ASCII        []string
+	Nozzles      []Point
+	Collision    *Sprite
+	Mass         float64
+	Thrust       float64
+	FuelCapacity float64
+	RCSPower     float64
+}
+
+var livery = map[rune]Colour{
+	'c': CYAN,
+	'w': WHITE,
+	'y': YELLOW,
+	'g': GREY,
+	'r': RED,
+	'b': BLUE,
+}
+
+var solid = map[rune]Colour{'x': WHITE}
+
+var ships = []*ShipDesign{
+	{
+		Name:        "Eagle",
+		Description: "The classic lander, good all round",
+		Sprite: newSprite([]string{
+			" c ",
+			"ywy",
+			"y y",
+		}, livery, 1, 2),
+		ASCII:   []string{"_o_", "/ \\"},
+		Nozzles: []Point{{0, 0}},
+		Collision: newSprite([]string{
+			" x ",
+			"xxx",
+			"x x",
+		}, solid, 1, 2),
+		Mass:         1.0,
+		Thrust:       1.0,
+		FuelCapacity: 100,
+		RCSPower:     1.0,
+	},
+	{
+		Name:        "Hopper",
+		Description: "Light and nimble with a small tank",
+		Sprite: newSprite([]string{
+			"c",
+			"w",
+			"y",
+		}, livery, 0, 2),
+		ASCII:   []string{"o", "|"},
+		Nozzles: []Point{{0, 0}},
+		Collision: newSprite([]string{
+			"x",
+			"x",
+			"x",
+		}, solid, 0, 2),
+		Mass:         0.7,
+		Thrust:       0.8,
+		FuelCapacity: 70,
+		RCSPower:     1.5,
+	},
+	{
+		Name:        "Hauler",
+		Description: "Heavy twin engine cargo lander",
+		Sprite: newSprite([]string{
+			" ccc ",
+			"gwwwg",
+			"gwbwg",
+			"y   y",
+		}, livery, 2, 3),
+		ASCII:   []string{"[=]", "/ \\"},
+		Nozzles: []Point{{-1, 0}, {1, 0}},
+		Collision: newSprite([]string{
+			" xxx ",
+			"xxxxx",
+			"xxxxx",
+			"x   x",
+		}, solid, 2, 3),
+		Mass:         1.6,
+		Thrust:       1.8,
+		FuelCapacity: 160,
+		RCSPower:     0.7,
+	},
+	{
+		Name:        "Dart",
+		Description: "Fast and agile but thirsty",
+		Sprite: newSprite([]string{
+			" r ",
+			" w ",
+			"ywy",
+			"y y",
+		}, livery, 1, 3),
+		ASCII:   []string{"A", "/|\\"},
+		Nozzles: []Point{{0, 0}},
+		Collision: newSprite([]string{
+			" x ",
+			" x ",
+			"xxx",
+			"x x",
+		}, solid, 1, 3),
+		Mass:         0.9,
+		Thrust:       1.3,
+		FuelCapacity: 80,
+		RCSPower:     2.0,
+	},
+}
+
+func findShip(name string) *ShipDesign {
+	for _, ship := range ships {
+		if strings.EqualFold(ship.Name, name) {
+			return ship
+		}
+	}
+	return ships[0]
+}
+
+// feet gives the offsets of the bottom row of the collision mask.
+func (ship *ShipDesign) feet() []Point {
+	mask := ship.Collision
+	feet := make([]Point, 0)
+	for x := 0; x < mask.W; x++ {
+		if mask.at(x, mask.H-1) != 0 {
+			feet = append(feet, Point{float64(x - mask.OriginX), float64(mask.H - 1 - mask.OriginY)})
+		}
+	}
+	return feet
+}
+
+// bounds gives the sub-pixel rectangle the collision mask covers with
+// the ship at x, y.
+func (ship *ShipDesign) bounds(x, y int) (int, int, int, int) {
+	mask := ship.Collision
+	x1 := x - mask.OriginX
+	y1 := y - mask.OriginY
+	return x1, y1, x1 + mask.W, y1 + mask.H
+}
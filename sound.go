@@ -0,0 +1,84 @@
+package main
+
+import (
+	"log"
+
+	"github.com/gdamore/tcell/v3"
+)
+
+// SoundEvent is something happening in the game worth a noise, what it
+// sounds like is up to the backend.
+type SoundEvent int
+
+const (
+	SoundEngineOn SoundEvent = iota
+	SoundEngineOff
+	SoundLowFuel
+	SoundMeteorHit
+	SoundCrash
+	SoundLanding
+	SoundMenuMove
+)
+
+var soundNames = []string{"engine on", "engine off", "low fuel", "meteor hit", "crash", "landing", "menu move"}
+
+func (e SoundEvent) String() string {
+	return soundNames[e]
+}
+
+// SoundBackend makes the noises. Play shouldn't block, it is called from
+// the game loop. Vario is the variometer's beep, pitch 1 being normal.
+type SoundBackend interface {
+	Play(event SoundEvent)
+	Vario(pitch float64)
+}
+
+// sound is set up by initSound for the platform being run on
+var sound SoundBackend
+
+// engineRunning is whether SoundEngineOn has been played without an off
+var engineRunning bool
+
+func initSound(s tcell.Screen) {
+	if settings.Headless {
+		return
+	}
+	sound = newSoundBackend(s)
+}
+
+func playSound(event SoundEvent) {
+	if sound == nil || settings.Mute {
+		return
+	}
+	Debug("Sound %s\n", event)
+	sound.Play(event)
+}
+
+// setEngine plays the engine starting or stopping when it changes.
+func setEngine(on bool) {
+	if on == engineRunning {
+		return
+	}
+	engineRunning = on
+	if on {
+		playSound(SoundEngineOn)
+	} else {
+		playSound(SoundEngineOff)
+	}
+}
+
+func muteLabel() string {
+	if settings.Mute {
+		return "Sound: Off"
+	}
+	return "Sound: On"
+}
+
+// toggleMute also silences an engine left running.
+func toggleMute() {
+	if !settings.Mute {
+		setEngine(false)
+	}
+	settings.Mute = !settings.Mute
+	log.Println("Sound muted", settings.Mute)
+}
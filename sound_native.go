@@ -0,0 +1,53 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v3"
+)
+
+// bellGap stops the bell ringing so often it turns into noise
+const bellGap = 750 * time.Millisecond
+
+// varioBellGap is the quickest the variometer rings the bell, its
+// continuous tone would otherwise be a buzz of bells
+const varioBellGap = 250 * time.Millisecond
+
+// BellSound rings the terminal bell for the events that matter, the
+// engine and menu would have it going all the time so they are quiet.
+type BellSound struct {
+	screen    tcell.Screen
+	last      time.Time
+	lastVario time.Time
+}
+
+func newSoundBackend(s tcell.Screen) SoundBackend {
+	return &BellSound{screen: s}
+}
+
+func (b *BellSound) Play(event SoundEvent) {
+	switch event {
+	case SoundLowFuel, SoundMeteorHit, SoundCrash, SoundLanding:
+	default:
+		return
+	}
+	// a crash always gets through, it is the one that matters most
+	if event != SoundCrash && time.Since(b.last) < bellGap {
+		return
+	}
+	b.last = time.Now()
+	_ = b.screen.Beep()
+}
+
+// Vario rings the bell at the variometer's pace up to varioBellGap,
+// the bell has no pitch. It keeps its own time so the events still get
+// through, and stays quiet just after one of them.
+func (b *BellSound) Vario(_ float64) {
+	if time.Since(b.lastVario) < varioBellGap || time.Since(b.last) < varioBellGap {
+		return
+	}
+	b.lastVario = time.Now()
+	_ = b.screen.Beep()
+}
@@ -0,0 +1,154 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"log"
+	"syscall/js"
+
+	"github.com/gdamore/tcell/v3"
+)
+
+// Tone is one oscillator note, sliding from From to To hertz.
+type Tone struct {
+	Wave     string
+	From     float64
+	To       float64
+	Start    float64
+	Duration float64
+	Volume   float64
+}
+
+// soundTones are synthesised for each event, the engine is a drone that
+// runs between on and off instead.
+var soundTones = map[SoundEvent][]Tone{
+	SoundLowFuel: {
+		{Wave: "square", From: 880, To: 880, Duration: 0.08, Volume: 0.1},
+		{Wave: "square", From: 660, To: 660, Start: 0.12, Duration: 0.08, Volume: 0.1},
+	},
+	SoundMeteorHit: {
+		{Wave: "sawtooth", From: 400, To: 60, Duration: 0.3, Volume: 0.25},
+	},
+	SoundCrash: {
+		{Wave: "sawtooth", From: 220, To: 30, Duration: 1.2, Volume: 0.35},
+		{Wave: "square", From: 90, To: 20, Duration: 1.0, Volume: 0.2},
+	},
+	SoundLanding: {
+		{Wave: "triangle", From: 523, To: 523, Duration: 0.12, Volume: 0.2},
+		{Wave: "triangle", From: 659, To: 659, Start: 0.12, Duration: 0.12, Volume: 0.2},
+		{Wave: "triangle", From: 784, To: 784, Start: 0.24, Duration: 0.3, Volume: 0.2},
+	},
+	SoundMenuMove: {
+		{Wave: "triangle", From: 660, To: 660, Duration: 0.04, Volume: 0.08},
+	},
+}
+
+// WebAudioSound synthesises the sounds in the browser. The audio context
+// is only made on the first sound as browsers want a key press first.
+type WebAudioSound struct {
+	context    js.Value
+	engine     js.Value
+	engineGain js.Value
+	missing    bool
+}
+
+func newSoundBackend(_ tcell.Screen) SoundBackend {
+	return &WebAudioSound{}
+}
+
+func (w *WebAudioSound) ready() bool {
+	if !w.context.IsUndefined() {
+		return true
+	}
+	if w.missing {
+		return false
+	}
+	audioContext := js.Global().Get("AudioContext")
+	if audioContext.IsUndefined() {
+		audioContext = js.Global().Get("webkitAudioContext")
+	}
+	if audioContext.IsUndefined() {
+		log.Println("No WebAudio, falling back to the bell")
+		w.missing = true
+		return false
+	}
+	w.context = audioContext.New()
+	return true
+}
+
+func (w *WebAudioSound) Play(event SoundEvent) {
+	if !w.ready() {
+		if event == SoundCrash || event == SoundLanding {
+			js.Global().Call("beep")
+		}
+		return
+	}
+	switch event {
+	case SoundEngineOn:
+		w.startEngine()
+	case SoundEngineOff:
+		w.stopEngine()
+	default:
+		for _, tone := range soundTones[event] {
+			w.play(tone)
+		}
+	}
+}
+
+func (w *WebAudioSound) play(tone Tone) {
+	now := w.context.Get("currentTime").Float()
+	start := now + tone.Start
+	end := start + tone.Duration
+	oscillator := w.context.Call("createOscillator")
+	oscillator.Set("type", tone.Wave)
+	oscillator.Get("frequency").Call("setValueAtTime", tone.From, start)
+	oscillator.Get("frequency").Call("exponentialRampToValueAtTime", tone.To, end)
+	gain := w.context.Call("createGain")
+	gain.Get("gain").Call("setValueAtTime", tone.Volume, start)
+	gain.Get("gain").Call("exponentialRampToValueAtTime", 0.001, end)
+	oscillator.Call("connect", gain)
+	gain.Call("connect", w.context.Get("destination"))
+	oscillator.Call("start", start)
+	oscillator.Call("stop", end)
+}
+
+// startEngine runs a low rumble until stopEngine fades it out.
+func (w *WebAudioSound) startEngine() {
+	if !w.engine.IsUndefined() {
+		return
+	}
+	now := w.context.Get("currentTime").Float()
+	oscillator := w.context.Call("createOscillator")
+	oscillator.Set("type", "sawtooth")
+	oscillator.Get("frequency").Call("setValueAtTime", 55, now)
+	filter := w.context.Call("createBiquadFilter")
+	filter.Set("type", "lowpass")
+	filter.Get("frequency").Call("setValueAtTime", 300, now)
+	gain := w.context.Call("createGain")
+	gain.Get("gain").Call("setValueAtTime", 0.001, now)
+	gain.Get("gain").Call("exponentialRampToValueAtTime", 0.15, now+0.1)
+	oscillator.Call("connect", filter)
+	filter.Call("connect", gain)
+	gain.Call("connect", w.context.Get("destination"))
+	oscillator.Call("start", now)
+	w.engine = oscillator
+	w.engineGain = gain
+}
+
+func (w *WebAudioSound) stopEngine() {
+	if w.engine.IsUndefined() {
+		return
+	}
+	now := w.context.Get("currentTime").Float()
+	gain := w.engineGain.Get("gain")
+	gain.Call("setValueAtTime", gain.Get("value"), now)
+	gain.Call("exponentialRampToValueAtTime", 0.001, now+0.2)
+	w.engine.Call("stop", now+0.2)
+	w.engine = js.Undefined()
+}
+
+// Vario plays beep.wav through the page's beep hook, sped up or slowed
+// down for the pitch.
+func (w *WebAudioSound) Vario(pitch float64) {
+	js.Global().Call("beep", pitch)
+}
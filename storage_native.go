@@ -0,0 +1,37 @@
+//go:build !js || !wasm
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// storePath is where a named store lives, under the user's config
+// directory so it survives wherever the game is run from.
+func storePath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "golunar", name+".json"), nil
+}
+
+func readStore(name string) ([]byte, error) {
+	path, err := storePath(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func writeStore(name string, data []byte) error {
+	path, err := storePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,41 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall/js"
+)
+
+// the browser keeps each store in localStorage under this prefix
+const storePrefix = "golunar-"
+
+func localStorage() (js.Value, error) {
+	storage := js.Global().Get("localStorage")
+	if storage.IsUndefined() || storage.IsNull() {
+		return js.Value{}, errors.New("no localStorage")
+	}
+	return storage, nil
+}
+
+func readStore(name string) ([]byte, error) {
+	storage, err := localStorage()
+	if err != nil {
+		return nil, err
+	}
+	item := storage.Call("getItem", storePrefix+name)
+	if item.IsNull() {
+		return nil, os.ErrNotExist
+	}
+	return []byte(item.String()), nil
+}
+
+func writeStore(name string, data []byte) error {
+	storage, err := localStorage()
+	if err != nil {
+		return err
+	}
+	storage.Call("setItem", storePrefix+name, string(data))
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+// maxNameLength is as long as a name the high score table can show
+const maxNameLength = 10
+
+func displayTextEntry(s tcell.Screen, title, prompt string, text []rune, limit int) {
+	styleTitle := tcell.StyleDefault.Foreground(color.Yellow).Background(color.Black)
+	styleNormal := tcell.StyleDefault.Foreground(color.White).Background(color.Black)
+	styleField := tcell.StyleDefault.Foreground(color.Black).Background(color.Green)
+
+	width, height := s.Size()
+	boxWidth := max(len(title), len(prompt), limit+2) + 8
+	boxHeight := 9
+	boxX := (width - boxWidth) / 2
+	boxY := (height - boxHeight) / 2
+	drawBox(s, boxX, boxY, boxWidth, boxHeight, styleNormal)
+
+	y := boxY + 2
+	drawTextCentre(s, width, y, styleTitle, title)
+	drawTextCentre(s, width, y+2, styleNormal, prompt)
+	// the field is padded out so it shows how long a name can be
+	field := string(text) + string(glyph('▏', '_')) + strings.Repeat(" ", limit-len(text))
+	if len(text) == limit {
+		field = string(text) + " "
+	}
+	fieldX := (width - (limit + 1)) / 2
+	drawText(s, fieldX, y+4, fieldX+limit+1, y+4, styleField, field)
+}
+
+// runTextEntry asks for a line of text up to limit characters, starting
+// with initial. It returns false if Escape was pressed instead.
+func runTextEntry(s tcell.Screen, title, prompt, initial string, limit int) (string, bool) {
+	text := []rune(initial)
+	if len(text) > limit {
+		text = text[:limit]
+	}
+
+	s.Clear()
+	for {
+		displayTextEntry(s, title, prompt, text, limit)
+		s.Show()
+
+		ev := <-s.EventQ()
+		switch ev := ev.(type) {
+		case *tcell.EventResize:
+			s.Sync()
+			s.Clear()
+		case *tcell.EventKey:
+			switch ev.Key() {
+			case tcell.KeyEnter:
+				if entered := strings.TrimSpace(string(text)); entered != "" {
+					s.Clear()
+					return entered, true
+				}
+			case tcell.KeyEscape:
+				s.Clear()
+				return initial, false
+			case tcell.KeyBackspace, tcell.KeyBackspace2:
+				if len(text) > 0 {
+					text = text[:len(text)-1]
+				}
+			case tcell.KeyRune:
+				for _, r := range ev.Str() {
+					if len(text) < limit && unicode.IsPrint(r) {
+						text = append(text, r)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/color"
+)
+
+// Trajectory is where the ship will go if the pilot does nothing more,
+// Points are sub-pixels along the way and Impact where the legs first
+// touch the ground if they do.
+type Trajectory struct {
+	Points      []Point
+	Impact      Point
+	ImpactSpeed float64
+	Hit         bool
+}
+
+// the ship falls slowly so look a long way ahead
+const trajectorySteps = 20000
+
+// predictTrajectory runs the game's physics forward with the engine off.
+func predictTrajectory(terrain *Canvas, ship *ShipDesign, x, y, speed, gravity, gravityIncrease, maxGravity float64) Trajectory {
+	var t Trajectory
+	feet := ship.feet()
+	lastY := int(y)
+	for step := 0; step < trajectorySteps; step++ {
+		if speed > maxGravity {
+			speed = maxGravity
+		}
+		gravity = gravity + gravityIncrease
+		if gravity > maxGravity {
+			gravity = maxGravity
+		}
+		y = y + speed
+		speed = speed + gravity
+		if y < 0 || int(y) >= terrain.Height {
+			return t
+		}
+		if int(y) != lastY {
+			lastY = int(y)
+			t.Points = append(t.Points, Point{x, y})
+		}
+		for _, foot := range feet {
+			if terrain.get(int(x+foot.X), int(y+foot.Y)+1) != 0 {
+				t.Hit = true
+				t.Impact = Point{x, y}
+				t.ImpactSpeed = speed
+				return t
+			}
+		}
+	}
+	return t
+}
+
+// drawTrajectory dots the path into the frame, skipping the sub-pixels
+// right under the ship so it doesn't look like exhaust.
+func drawTrajectory(c *Canvas, t Trajectory) {
+	for i, p := range t.Points {
+		if i < 3 || i%2 == 1 {
+			continue
+		}
+		c.set(int(p.X), int(p.Y), CYAN.scale(0.5))
+	}
+}
+
+// drawImpactMarker marks where the ship will come down, green if it
+// would be a safe landing there and red if not.
+func drawImpactMarker(s tcell.Screen, c *Canvas, t Trajectory, safe bool) {
+	if !t.Hit {
+		return
+	}
+	style := tcell.StyleDefault.Foreground(color.Red).Background(color.Black)
+	if safe {
+		style = style.Foreground(color.Green)
+	}
+	s.SetContent(int(t.Impact.X)/c.CellW, (int(t.Impact.Y)+1)/c.CellH, 'X', nil, style)
+}
@@ -0,0 +1,63 @@
+package main
+
+import "math"
+
+// Variometer beeps faster and higher the quicker the lander is coming
+// down against the safe landing speed and the closer it is to the
+// ground, like a glider pilot's vario. Past the safe speed it goes
+// continuous.
+type Variometer struct {
+	wait int
+}
+
+const (
+	// varioSlowest is the frames between beeps at a gentle descent high up
+	varioSlowest = 60
+	// varioContinuous is the frames between beeps once the descent is unsafe
+	varioContinuous = 4
+	// varioNearRows is how many rows from the ground the beeps speed up
+	varioNearRows = 10
+	// varioNear is varioNearRows as the radar altitude reads it
+	varioNear = varioNearRows * radarPerRow
+)
+
+// update is called every frame with the descent speed, the safe landing
+// speed and the radar altitude from radarAltitude, beeping when one is due.
+func (v *Variometer) update(speed, safe, altitude float64) {
+	if speed <= 0 || safe <= 0 {
+		// going up or hovering, nothing to warn about
+		v.wait = 0
+		return
+	}
+	if speed >= safe && v.wait > varioContinuous {
+		// don't sit out a slow beep once it has become dangerous
+		v.wait = 0
+	}
+	if v.wait > 0 {
+		v.wait--
+		return
+	}
+	ratio := speed / safe
+	interval := varioContinuous
+	if ratio < 1 {
+		closeness := math.Max(0.25, math.Min(1, altitude/varioNear))
+		interval = max(varioContinuous*2, int(varioSlowest*(1-ratio*0.75)*closeness))
+	}
+	v.wait = interval
+	// an octave from slow to the safe speed, and a little higher beyond
+	playVario(0.75 + math.Min(ratio, 1.5)*0.75)
+}
+
+func playVario(pitch float64) {
+	if sound == nil || settings.Mute {
+		return
+	}
+	sound.Vario(pitch)
+}
+
+func varioLabel() string {
+	if settings.Vario {
+		return "Variometer: On"
+	}
+	return "Variometer: Off"
+}
@@ -212,7 +212,9 @@ function setCursorStyle(newClass, newColor) {
   cursorColor = newColor;
 }
 
-function beep() {
+function beep(rate) {
+  beepAudio.playbackRate = rate || 1;
+  beepAudio.preservesPitch = false;
   beepAudio.currentTime = 0;
   beepAudio.play();
 }